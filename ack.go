@@ -0,0 +1,150 @@
+package miio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// Time to wait for the write acknowledgement.
+	writeAckTimeout = 5 * time.Second
+	// Time to wait for a new token. Gateway sends heartbeat every 10 seconds.
+	tokenRefreshTimeout = 15 * time.Second
+
+	gwErrInvalidKey    = "Invalid key"
+	gwErrInvalidDevice = "Invalid device"
+)
+
+var (
+	// ErrAckTimeout is returned if gateway didn't acknowledge a write.
+	ErrAckTimeout = errors.New("timeout while waiting for gateway acknowledgement")
+	// ErrInvalidKey is returned if gateway rejected the write key.
+	ErrInvalidKey = errors.New("gateway rejected the key")
+	// ErrInvalidDevice is returned if gateway doesn't know the device.
+	ErrInvalidDevice = errors.New("gateway rejected the device")
+)
+
+// Gateway error response.
+type ackError struct {
+	Error string `json:"error"`
+}
+
+// Performs a write command and waits for the acknowledgement.
+// Retries once with a fresh token if gateway rejects the key.
+func (g *Gateway) write(data map[string]interface{}, sid, model string) error {
+	g.writeLock.Lock()
+	defer g.writeLock.Unlock()
+
+	err := g.writeOnce(data, sid, model)
+	if err != ErrInvalidKey {
+		return err
+	}
+
+	LOGGER.Warn("Gateway token is stale, waiting for a heartbeat")
+	if !g.waitForToken() {
+		return err
+	}
+
+	return g.writeOnce(data, sid, model)
+}
+
+// Sends a single write command and waits for the acknowledgement.
+func (g *Gateway) writeOnce(data map[string]interface{}, sid, model string) error {
+	ack := make(chan *command, 1)
+	g.ackLock.Lock()
+	g.acks[sid] = ack
+	g.ackLock.Unlock()
+
+	defer func() {
+		g.ackLock.Lock()
+		delete(g.acks, sid)
+		g.ackLock.Unlock()
+	}()
+
+	err := g.commandWithModel(cmdSetDeviceState, data, sid, model)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case cmd := <-ack:
+		return parseAckError(cmd)
+	case <-time.After(writeAckTimeout):
+		LOGGER.Error("Timeout while waiting on write acknowledgement for %s", sid)
+		return ErrAckTimeout
+	}
+}
+
+// Delivers the write acknowledgement to a waiting writer.
+// Returns false if acknowledgement contains an error.
+func (g *Gateway) processWriteAck(cmd *command) bool {
+	g.ackLock.Lock()
+	ack, ok := g.acks[cmd.Sid]
+	g.ackLock.Unlock()
+
+	if ok {
+		select {
+		case ack <- cmd:
+		default:
+		}
+	}
+
+	return nil == parseAckError(cmd)
+}
+
+// Waits for the next token from the gateway.
+func (g *Gateway) waitForToken() bool {
+	g.tokenLock.Lock()
+	updated := g.tokenUpdated
+	g.tokenLock.Unlock()
+
+	select {
+	case <-updated:
+		return true
+	case <-time.After(tokenRefreshTimeout):
+		LOGGER.Error("Timeout while waiting on a new gateway token")
+		return false
+	}
+}
+
+// Sets a new token and notifies waiting writers.
+func (g *Gateway) setToken(token string) {
+	g.tokenLock.Lock()
+	defer g.tokenLock.Unlock()
+
+	if token == g.token {
+		return
+	}
+
+	g.token = token
+	close(g.tokenUpdated)
+	g.tokenUpdated = make(chan struct{})
+}
+
+// Returns the current token.
+func (g *Gateway) getToken() string {
+	g.tokenLock.Lock()
+	defer g.tokenLock.Unlock()
+
+	return g.token
+}
+
+// Parses the error from the gateway acknowledgement.
+func parseAckError(cmd *command) error {
+	e := &ackError{}
+	err := json.Unmarshal([]byte(cmd.Data), e)
+	if err != nil || "" == e.Error {
+		return nil
+	}
+
+	switch e.Error {
+	case gwErrInvalidKey:
+		return ErrInvalidKey
+	case gwErrInvalidDevice:
+		return ErrInvalidDevice
+	default:
+		return fmt.Errorf("gateway error: %s", e.Error)
+	}
+}
@@ -0,0 +1,171 @@
+package miio
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// Interval between availability checks of gateway devices.
+	availabilityCheckInterval = 5 * time.Second
+	// Interval between pings of standalone devices.
+	pingInterval = 30 * time.Second
+	// Standalone device is considered offline after missing this many pings.
+	pingMissesOffline = 3
+	// Gateway without heartbeats is considered offline after missing this many polls.
+	pollMissesOffline = 3
+	// Timeout used for unknown gateway device models.
+	defaultSubDeviceTimeout = 2 * time.Hour
+)
+
+var (
+	// Gateway reports a heartbeat every 10 seconds, sub-devices roughly once an hour.
+	availabilityTimeouts = map[gatewayDeviceModel]time.Duration{
+		devGateway:    30 * time.Second,
+		devSwitch:     2 * time.Hour,
+		devSensorHT:   2 * time.Hour,
+		devMagnet:     2 * time.Hour,
+		devMotion:     2 * time.Hour,
+		devWallSwitch: 2 * time.Hour,
+		devPlug:       2 * time.Hour,
+		devWaterLeak:  2 * time.Hour,
+		devSmoke:      2 * time.Hour,
+		devNatgas:     2 * time.Hour,
+		devCube:       2 * time.Hour,
+		devVibration:  2 * time.Hour,
+		devCurtain:    2 * time.Hour,
+		devRemote:     2 * time.Hour,
+		devLock:       2 * time.Hour,
+	}
+)
+
+// AvailabilityState describes device reachability.
+type AvailabilityState struct {
+	Online   bool
+	LastSeen time.Time
+}
+
+// Device availability tracker.
+type availability struct {
+	sync.RWMutex
+
+	online   bool
+	lastSeen time.Time
+	timeout  time.Duration
+}
+
+// Sets the timeout after which device is considered offline.
+func (a *availability) setTimeout(timeout time.Duration) {
+	a.Lock()
+	defer a.Unlock()
+
+	a.timeout = timeout
+}
+
+// Marks device as seen. Returns true if device went online.
+func (a *availability) seen() bool {
+	a.Lock()
+	defer a.Unlock()
+
+	a.lastSeen = time.Now()
+	if a.online {
+		return false
+	}
+
+	a.online = true
+	return true
+}
+
+// Checks whether device has timed out. Returns true if device went offline.
+func (a *availability) check() bool {
+	a.Lock()
+	defer a.Unlock()
+
+	if !a.online || 0 == a.timeout {
+		return false
+	}
+
+	if a.lastSeen.Add(a.timeout).After(time.Now()) {
+		return false
+	}
+
+	a.online = false
+	return true
+}
+
+// Returns current availability state.
+func (a *availability) state() *AvailabilityState {
+	a.RLock()
+	defer a.RUnlock()
+
+	return &AvailabilityState{
+		Online:   a.online,
+		LastSeen: a.lastSeen,
+	}
+}
+
+// Returns availability tracker.
+func (d *XiaomiDevice) getAvailability() *availability {
+	return &d.avail
+}
+
+// Online returns whether device is reachable.
+func (d *XiaomiDevice) Online() bool {
+	d.avail.RLock()
+	defer d.avail.RUnlock()
+	return d.avail.online
+}
+
+// LastSeen returns the last time device has been heard from.
+func (d *XiaomiDevice) LastSeen() time.Time {
+	d.avail.RLock()
+	defer d.avail.RUnlock()
+	return d.avail.lastSeen
+}
+
+// Returns availability update message.
+func (d *XiaomiDevice) availabilityMessage(id, model string) *DeviceUpdateMessage {
+	return &DeviceUpdateMessage{
+		ID:    id,
+		Model: model,
+		Kind:  UpdateKindAvailability,
+		State: d.avail.state(),
+	}
+}
+
+// Pings standalone device and notifies about availability changes.
+func (d *XiaomiDevice) monitorAvailability(id, model string, notify func(*DeviceUpdateMessage)) {
+	d.avail.setTimeout(pingInterval * pingMissesOffline)
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		if d.stopped() {
+			return
+		}
+
+		if d.ping() {
+			if d.avail.seen() {
+				notify(d.availabilityMessage(id, model))
+			}
+		} else if d.avail.check() {
+			notify(d.availabilityMessage(id, model))
+		}
+
+		select {
+		case <-d.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Returns timeout for the gateway device model.
+func availabilityTimeout(model gatewayDeviceModel) time.Duration {
+	t, ok := availabilityTimeouts[model]
+	if !ok {
+		return defaultSubDeviceTimeout
+	}
+
+	return t
+}
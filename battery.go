@@ -0,0 +1,116 @@
+package miio
+
+const (
+	// Battery level considered low, percent.
+	lowBatteryPercent = 10
+)
+
+// Point of a battery discharge curve.
+type batteryPoint struct {
+	voltage uint32
+	percent float32
+}
+
+// Battery discharge curve, points are sorted by voltage descending.
+type batteryCurve []batteryPoint
+
+var (
+	batteryCR2032 = batteryCurve{
+		{3000, 100},
+		{2900, 42},
+		{2740, 18},
+		{2440, 6},
+		{2100, 0},
+	}
+
+	batteryCR2450 = batteryCurve{
+		{3000, 100},
+		{2950, 80},
+		{2850, 45},
+		{2700, 18},
+		{2500, 5},
+		{2200, 0},
+	}
+
+	batteryCR1632 = batteryCurve{
+		{3000, 100},
+		{2900, 55},
+		{2750, 25},
+		{2550, 8},
+		{2200, 0},
+	}
+
+	// Battery types used by gateway sub-devices.
+	batteryCurves = map[string]batteryCurve{
+		"switch":             batteryCR2032,
+		"sensor_switch.aq2":  batteryCR2032,
+		"sensor_switch.aq3":  batteryCR2032,
+		"sensor_ht":          batteryCR2032,
+		"weather.v1":         batteryCR2032,
+		"sensor_wleak.aq1":   batteryCR2032,
+		"vibration":          batteryCR2032,
+		"86sw1":              batteryCR2032,
+		"86sw2":              batteryCR2032,
+		"sensor_86sw1":       batteryCR2032,
+		"sensor_86sw2":       batteryCR2032,
+		"remote.b186acn01":   batteryCR2032,
+		"remote.b286acn01":   batteryCR2032,
+		"magnet":             batteryCR1632,
+		"sensor_magnet.aq2":  batteryCR1632,
+		"motion":             batteryCR2450,
+		"sensor_motion.aq2":  batteryCR2450,
+		"cube":               batteryCR2450,
+		"sensor_cube":        batteryCR2450,
+		"sensor_cube.aqgl01": batteryCR2450,
+	}
+)
+
+// BatteryState describes a state of the battery powered device.
+type BatteryState struct {
+	// Battery level, percent.
+	Battery float32
+	// Voltage reported by the device, mV.
+	Voltage    uint32
+	LowBattery bool
+}
+
+// Returns battery level for the voltage, percent.
+func (c batteryCurve) percent(voltage uint32) float32 {
+	if voltage >= c[0].voltage {
+		return c[0].percent
+	}
+
+	for ii := 1; ii < len(c); ii++ {
+		if voltage < c[ii].voltage {
+			continue
+		}
+
+		hi, lo := c[ii-1], c[ii]
+		ratio := float32(voltage-lo.voltage) / float32(hi.voltage-lo.voltage)
+		return lo.percent + ratio*(hi.percent-lo.percent)
+	}
+
+	return c[len(c)-1].percent
+}
+
+// Returns battery curve for the device model.
+func batteryCurveFor(model string) batteryCurve {
+	c, ok := batteryCurves[model]
+	if !ok {
+		return batteryCR2032
+	}
+
+	return c
+}
+
+// Updates battery state from the reported voltage.
+func (d *XiaomiDevice) updateBattery(b *BatteryState) {
+	_, ok := d.rawState[fieldVoltage.String()]
+	if !ok {
+		return
+	}
+
+	b.Voltage = d.GetFieldValueUint32(fieldVoltage, b.Voltage)
+	b.Battery = batteryCurveFor(d.model).percent(b.Voltage)
+	b.LowBattery = b.Battery <= lowBatteryPercent
+}
@@ -0,0 +1,45 @@
+package miio
+
+import "testing"
+
+func TestBatteryCurvePercent(t *testing.T) {
+	tests := []struct {
+		name    string
+		curve   batteryCurve
+		voltage uint32
+		want    float32
+	}{
+		{"above max", batteryCR2032, 3300, 100},
+		{"max", batteryCR2032, 3000, 100},
+		{"curve point", batteryCR2032, 2900, 42},
+		{"interpolated", batteryCR2032, 2950, 71},
+		{"interpolated lower segment", batteryCR2450, 2600, 11.5},
+		{"last point", batteryCR2032, 2100, 0},
+		{"below last point", batteryCR2032, 1800, 0},
+		{"zero", batteryCR1632, 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.curve.percent(tt.voltage); got != tt.want {
+			t.Errorf("%s: percent(%d) = %v, want %v", tt.name, tt.voltage, got, tt.want)
+		}
+	}
+}
+
+func TestBatteryCurveFor(t *testing.T) {
+	tests := []struct {
+		model string
+		want  batteryCurve
+	}{
+		{"sensor_magnet.aq2", batteryCR1632},
+		{"sensor_motion.aq2", batteryCR2450},
+		{"sensor_ht", batteryCR2032},
+		{"unknown", batteryCR2032},
+	}
+
+	for _, tt := range tests {
+		if got := batteryCurveFor(tt.model); &got[0] != &tt.want[0] {
+			t.Errorf("%s: unexpected battery curve", tt.model)
+		}
+	}
+}
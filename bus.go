@@ -0,0 +1,270 @@
+package miio
+
+import (
+	"sync"
+)
+
+const (
+	// Default subscription buffer size.
+	defaultSubscriptionBuffer = 50
+)
+
+// UpdateKind defines the kind of an update message.
+type UpdateKind int
+
+const (
+	// UpdateKindState describes device state update.
+	UpdateKindState UpdateKind = iota
+	// UpdateKindAvailability describes device availability change.
+	UpdateKindAvailability
+	// UpdateKindEvent describes a momentary event, State contains *Event.
+	UpdateKindEvent
+)
+
+// OverflowPolicy defines behaviour when subscriber's buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDropOldest discards the oldest buffered message.
+	OverflowDropOldest OverflowPolicy = iota
+	// OverflowDropNewest discards the message being published.
+	OverflowDropNewest
+	// OverflowBlock blocks publisher until subscriber reads the message.
+	OverflowBlock
+	// OverflowBlockHighPriority discards new regular messages, but blocks publisher
+	// for high priority events, so they are never discarded.
+	OverflowBlockHighPriority
+)
+
+// UpdateFilter defines subscription filter.
+// Empty lists match everything.
+type UpdateFilter struct {
+	IDs    []string
+	Models []string
+	Kinds  []UpdateKind
+}
+
+// Checks whether message passes the filter.
+func (f *UpdateFilter) matches(msg *DeviceUpdateMessage) bool {
+	if nil == f {
+		return true
+	}
+
+	if len(f.IDs) > 0 && !containsString(f.IDs, msg.ID) {
+		return false
+	}
+
+	if len(f.Models) > 0 && !containsString(f.Models, msg.Model) {
+		return false
+	}
+
+	if len(f.Kinds) > 0 {
+		found := false
+		for _, v := range f.Kinds {
+			if v == msg.Kind {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Subscription defines a single subscriber.
+type Subscription struct {
+	// C delivers messages for channel subscriptions. Nil for callback subscriptions.
+	C <-chan *DeviceUpdateMessage
+
+	filter   *UpdateFilter
+	policy   OverflowPolicy
+	messages chan *DeviceUpdateMessage
+	done     chan struct{}
+	doneOnce sync.Once
+	busQuit  <-chan struct{}
+}
+
+// Delivers message to the subscriber.
+func (s *Subscription) deliver(msg *DeviceUpdateMessage) {
+	policy := s.policy
+	if OverflowBlockHighPriority == policy {
+		policy = OverflowDropNewest
+		if msg.isHighPriority() {
+			policy = OverflowBlock
+		}
+	}
+
+	switch policy {
+	case OverflowBlock:
+		select {
+		case s.messages <- msg:
+		case <-s.done:
+		case <-s.busQuit:
+		}
+	case OverflowDropNewest:
+		select {
+		case s.messages <- msg:
+		default:
+			LOGGER.Warn("Subscriber is full, dropping update for %s", msg.ID)
+		}
+	default:
+		for {
+			select {
+			case s.messages <- msg:
+				return
+			default:
+			}
+
+			select {
+			case <-s.messages:
+				LOGGER.Warn("Subscriber is full, dropping oldest update")
+			default:
+			}
+		}
+	}
+}
+
+// Marks subscription as finished.
+func (s *Subscription) finish() {
+	s.doneOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// UpdateBus dispatches device updates to subscribers.
+type UpdateBus struct {
+	mu       sync.RWMutex
+	subs     map[*Subscription]bool
+	closed   bool
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+// NewUpdateBus creates a new update bus.
+func NewUpdateBus() *UpdateBus {
+	return &UpdateBus{
+		subs: make(map[*Subscription]bool),
+		quit: make(chan struct{}),
+	}
+}
+
+// Subscribe creates a new channel subscription.
+func (b *UpdateBus) Subscribe(filter *UpdateFilter, size int, policy OverflowPolicy) *Subscription {
+	s := b.subscribe(filter, size, policy)
+	s.C = s.messages
+	return s
+}
+
+// SubscribeFunc creates a new subscription which invokes callback for every message.
+// Callback is invoked from a separate goroutine, one message at a time.
+func (b *UpdateBus) SubscribeFunc(filter *UpdateFilter, size int, policy OverflowPolicy,
+	fn func(*DeviceUpdateMessage)) *Subscription {
+	s := b.subscribe(filter, size, policy)
+	go func() {
+		for msg := range s.messages {
+			fn(msg)
+		}
+	}()
+
+	return s
+}
+
+// Unsubscribe removes the subscription and closes its channel.
+func (b *UpdateBus) Unsubscribe(s *Subscription) {
+	s.finish()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[s]; !ok {
+		return
+	}
+
+	delete(b.subs, s)
+	close(s.messages)
+}
+
+// Registers a new subscription.
+func (b *UpdateBus) subscribe(filter *UpdateFilter, size int, policy OverflowPolicy) *Subscription {
+	if size <= 0 {
+		size = defaultSubscriptionBuffer
+	}
+
+	s := &Subscription{
+		filter:   filter,
+		policy:   policy,
+		messages: make(chan *DeviceUpdateMessage, size),
+		done:     make(chan struct{}),
+		busQuit:  b.quit,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		s.finish()
+		close(s.messages)
+		return s
+	}
+
+	b.subs[s] = true
+	return s
+}
+
+// Publishes a message to all matching subscribers.
+func (b *UpdateBus) publish(msg *DeviceUpdateMessage) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return
+	}
+
+	for s := range b.subs {
+		if s.filter.matches(msg) {
+			s.deliver(msg)
+		}
+	}
+}
+
+// Closes all subscriptions.
+func (b *UpdateBus) close() {
+	b.quitOnce.Do(func() {
+		close(b.quit)
+	})
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+	for s := range b.subs {
+		s.finish()
+		delete(b.subs, s)
+		close(s.messages)
+	}
+}
+
+// Checks whether message is a high priority event.
+func (msg *DeviceUpdateMessage) isHighPriority() bool {
+	e, ok := msg.State.(*Event)
+	return ok && PriorityHigh == e.Priority
+}
+
+// Checks whether slice contains a string.
+func containsString(list []string, val string) bool {
+	for _, v := range list {
+		if v == val {
+			return true
+		}
+	}
+
+	return false
+}
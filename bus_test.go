@@ -0,0 +1,137 @@
+package miio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpdateFilterMatches(t *testing.T) {
+	msg := &DeviceUpdateMessage{ID: "abc", Model: "magnet", Kind: UpdateKindState}
+	tests := []struct {
+		name   string
+		filter *UpdateFilter
+		want   bool
+	}{
+		{"nil filter", nil, true},
+		{"empty filter", &UpdateFilter{}, true},
+		{"matching id", &UpdateFilter{IDs: []string{"xyz", "abc"}}, true},
+		{"other id", &UpdateFilter{IDs: []string{"xyz"}}, false},
+		{"matching model", &UpdateFilter{Models: []string{"magnet"}}, true},
+		{"other model", &UpdateFilter{Models: []string{"motion"}}, false},
+		{"matching kind", &UpdateFilter{Kinds: []UpdateKind{UpdateKindEvent, UpdateKindState}}, true},
+		{"other kind", &UpdateFilter{Kinds: []UpdateKind{UpdateKindAvailability}}, false},
+		{"all match", &UpdateFilter{IDs: []string{"abc"}, Models: []string{"magnet"},
+			Kinds: []UpdateKind{UpdateKindState}}, true},
+		{"one mismatch", &UpdateFilter{IDs: []string{"abc"}, Models: []string{"motion"}}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filter.matches(msg); got != tt.want {
+			t.Errorf("%s: matches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSubscriptionOverflow(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy OverflowPolicy
+		want   []string
+	}{
+		{"drop oldest", OverflowDropOldest, []string{"2", "3"}},
+		{"drop newest", OverflowDropNewest, []string{"1", "2"}},
+		{"block high priority drops regular", OverflowBlockHighPriority, []string{"1", "2"}},
+	}
+
+	for _, tt := range tests {
+		b := NewUpdateBus()
+		s := b.Subscribe(nil, 2, tt.policy)
+		for _, id := range []string{"1", "2", "3"} {
+			b.publish(&DeviceUpdateMessage{ID: id})
+		}
+
+		got := drain(s)
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+
+		for ii := range got {
+			if got[ii] != tt.want[ii] {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+
+		b.close()
+	}
+}
+
+func TestSubscriptionBlock(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy OverflowPolicy
+		msg    *DeviceUpdateMessage
+	}{
+		{"block", OverflowBlock, &DeviceUpdateMessage{ID: "2"}},
+		{"block high priority", OverflowBlockHighPriority, &DeviceUpdateMessage{ID: "2",
+			Kind: UpdateKindEvent, State: &Event{Type: EventLeak, Priority: PriorityHigh}}},
+	}
+
+	for _, tt := range tests {
+		b := NewUpdateBus()
+		s := b.Subscribe(nil, 1, tt.policy)
+		b.publish(&DeviceUpdateMessage{ID: "1"})
+
+		published := make(chan struct{})
+		go func() {
+			b.publish(tt.msg)
+			close(published)
+		}()
+
+		select {
+		case <-published:
+			t.Fatalf("%s: publish didn't block on a full buffer", tt.name)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		if msg := <-s.C; "1" != msg.ID {
+			t.Errorf("%s: got %s, want 1", tt.name, msg.ID)
+		}
+
+		select {
+		case <-published:
+		case <-time.After(time.Second):
+			t.Fatalf("%s: publish is still blocked", tt.name)
+		}
+
+		if msg := <-s.C; "2" != msg.ID {
+			t.Errorf("%s: got %s, want 2", tt.name, msg.ID)
+		}
+
+		b.close()
+	}
+}
+
+func TestUnsubscribeClosesChannel(t *testing.T) {
+	b := NewUpdateBus()
+	s := b.Subscribe(nil, 1, OverflowBlock)
+	b.Unsubscribe(s)
+	b.publish(&DeviceUpdateMessage{ID: "1"})
+
+	if _, ok := <-s.C; ok {
+		t.Error("channel is not closed after unsubscribe")
+	}
+}
+
+// Reads all buffered message IDs.
+func drain(s *Subscription) []string {
+	ids := make([]string, 0)
+	for {
+		select {
+		case msg := <-s.C:
+			ids = append(ids, msg.ID)
+		default:
+			return ids
+		}
+	}
+}
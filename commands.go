@@ -8,17 +8,28 @@ type deviceDTO struct {
 	Token string `json:"token,omitempty"`
 }
 
+// Gateway device list item, protocol v2.
+type devListItem struct {
+	Sid   string `json:"sid"`
+	Model string `json:"model"`
+}
+
 // Gateway command.
 type command struct {
 	deviceDTO
 	Cmd string `json:"cmd"`
+
+	// Protocol v2 fields.
+	Key     string                   `json:"key,omitempty"`
+	Params  []map[string]interface{} `json:"params,omitempty"`
+	DevList []*devListItem           `json:"dev_list,omitempty"`
 }
 
 // Independent device command.
 type deviceCommand struct {
-	ID     int64         `json:"id"`
-	Method string        `json:"method"`
-	Params []interface{} `json:"params,omitempty"`
+	ID     int64       `json:"id"`
+	Method string      `json:"method"`
+	Params interface{} `json:"params,omitempty"`
 }
 
 // Base response from the device.
@@ -18,14 +18,14 @@ type connection struct {
 	DeviceMessages chan []byte
 }
 
-// Creates a new connection.
-func newConnection(ip string, port int) (*connection, error) {
+// Creates a new connection. Local address is optional.
+func newConnection(ip string, port int, local *net.UDPAddr) (*connection, error) {
 	addr := &net.UDPAddr{
 		IP:   net.ParseIP(ip),
 		Port: port,
 	}
 
-	con, err := net.DialUDP("udp4", nil, addr)
+	con, err := net.DialUDP("udp4", local, addr)
 	if err != nil {
 		return nil, err
 	}
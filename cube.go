@@ -0,0 +1,116 @@
+package miio
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// Cube gestures reported in the status field.
+	cubeGestures = map[string]EventType{
+		"flip90":    EventFlip90,
+		"flip180":   EventFlip180,
+		"move":      EventMove,
+		"tap_twice": EventTapTwice,
+		"shake_air": EventShakeAir,
+		"swing":     EventSwing,
+		"alert":     EventAlert,
+		"free_fall": EventFreeFall,
+	}
+)
+
+// CubeRotation describes cube rotation.
+type CubeRotation struct {
+	// Rotation angle, degrees. Negative values mean counter-clockwise rotation.
+	Angle    int
+	Duration time.Duration
+}
+
+// CubeState describes a state of the cube.
+type CubeState struct {
+	BatteryState
+
+	LastAction time.Time
+}
+
+// Cube defines an Aqara cube.
+type Cube struct {
+	XiaomiDevice
+
+	ID      string
+	Gateway *Gateway
+	state   *CubeState
+}
+
+// Stops is not uses for gateway devices.
+func (c *Cube) Stop() {
+}
+
+// GetUpdateMessage returns device's state update message.
+func (c *Cube) GetUpdateMessage() *DeviceUpdateMessage {
+	c.Gateway.Lock()
+	defer c.Gateway.Unlock()
+
+	return c.updateMessage()
+}
+
+// Returns device's state update message. Gateway lock must be held.
+func (c *Cube) updateMessage() *DeviceUpdateMessage {
+	return &DeviceUpdateMessage{
+		ID:      c.ID,
+		Model:   c.model,
+		Kind:    UpdateKindState,
+		Version: c.version,
+		State:   c.stateCopy(),
+	}
+}
+
+// Returns a copy of the device state.
+func (c *Cube) stateCopy() interface{} {
+	st := *c.state
+	return &st
+}
+
+// UpdateState performs a device update.
+func (c *Cube) UpdateState() {
+	c.updateBattery(&c.state.BatteryState)
+
+	if t, ok := cubeGestures[c.getFieldValue(fieldStatus)]; ok {
+		c.addEvent(t)
+		c.state.LastAction = time.Now()
+	}
+
+	rotation := parseCubeRotation(c.getFieldValue(fieldRotate))
+	if nil != rotation {
+		c.addEventData(EventRotate, rotation)
+		c.state.LastAction = time.Now()
+	}
+}
+
+// Parses rotation value, reported as "angle,duration_ms".
+func parseCubeRotation(val string) *CubeRotation {
+	if "" == val {
+		return nil
+	}
+
+	parts := strings.Split(val, ",")
+	angle, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		LOGGER.Warn("Failed to parse cube rotation: %s", val)
+		return nil
+	}
+
+	r := &CubeRotation{
+		Angle: angle,
+	}
+
+	if len(parts) > 1 {
+		ms, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err == nil {
+			r.Duration = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	return r
+}
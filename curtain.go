@@ -0,0 +1,107 @@
+package miio
+
+import "fmt"
+
+// CurtainState describes a state of the curtain controller.
+type CurtainState struct {
+	// Position, percent. 0 is closed, 100 is fully open.
+	Position int
+	Moving   bool
+}
+
+// Curtain defines an Aqara curtain controller.
+// Motor is stopped with StopMotor, since Stop is the device lifecycle method.
+type Curtain struct {
+	XiaomiDevice
+
+	ID      string
+	Gateway *Gateway
+	state   *CurtainState
+}
+
+// Stops is not uses for gateway devices.
+func (c *Curtain) Stop() {
+}
+
+// GetUpdateMessage returns device's state update message.
+func (c *Curtain) GetUpdateMessage() *DeviceUpdateMessage {
+	c.Gateway.Lock()
+	defer c.Gateway.Unlock()
+
+	return c.updateMessage()
+}
+
+// Returns device's state update message. Gateway lock must be held.
+func (c *Curtain) updateMessage() *DeviceUpdateMessage {
+	return &DeviceUpdateMessage{
+		ID:      c.ID,
+		Model:   c.model,
+		Kind:    UpdateKindState,
+		Version: c.version,
+		State:   c.stateCopy(),
+	}
+}
+
+// Returns a copy of the device state.
+func (c *Curtain) stateCopy() interface{} {
+	st := *c.state
+	return &st
+}
+
+// UpdateState performs a device update.
+func (c *Curtain) UpdateState() {
+	switch c.getFieldValue(fieldCurtainStatus) {
+	case valueOpen, valueClose:
+		c.state.Moving = true
+	case valueStop:
+		c.state.Moving = false
+	}
+
+	if "" == c.getFieldValue(fieldCurtainLevel) {
+		return
+	}
+
+	c.state.Position = int(c.GetFieldValueInt32(fieldCurtainLevel, int32(c.state.Position)))
+	// Motor doesn't report stop after reaching the end position.
+	if 0 == c.state.Position || 100 == c.state.Position {
+		c.state.Moving = false
+	}
+}
+
+// Open fully opens the curtain.
+func (c *Curtain) Open() error {
+	return c.setStatus(valueOpen)
+}
+
+// Close fully closes the curtain.
+func (c *Curtain) Close() error {
+	return c.setStatus(valueClose)
+}
+
+// StopMotor stops the curtain motor.
+// Stop is the no-op lifecycle method of gateway devices and doesn't move the curtain.
+func (c *Curtain) StopMotor() error {
+	return c.setStatus(valueStop)
+}
+
+// SetPosition moves the curtain to the position, percent.
+func (c *Curtain) SetPosition(pct int) error {
+	if pct < 0 || pct > 100 {
+		return fmt.Errorf("invalid curtain position %d", pct)
+	}
+
+	data := map[string]interface{}{
+		fieldCurtainLevel.String(): fmt.Sprintf("%d", pct),
+	}
+
+	return c.Gateway.write(data, c.ID, c.model)
+}
+
+// Sends the curtain status to the gateway.
+func (c *Curtain) setStatus(val string) error {
+	data := map[string]interface{}{
+		fieldCurtainStatus.String(): val,
+	}
+
+	return c.Gateway.write(data, c.ID, c.model)
+}
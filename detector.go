@@ -0,0 +1,125 @@
+package miio
+
+// AlarmStatus defines smoke or gas detector alarm status.
+type AlarmStatus int
+
+const (
+	// AlarmNone describes no alarm.
+	AlarmNone AlarmStatus = iota
+	// AlarmActive describes detected smoke or gas.
+	AlarmActive
+	// AlarmTest describes self-test alarm.
+	AlarmTest
+	// AlarmBatteryFault describes battery failure.
+	AlarmBatteryFault
+	// AlarmSensorFault describes sensor failure.
+	AlarmSensorFault
+	// AlarmCommunicationFault describes internal communication failure.
+	AlarmCommunicationFault
+	// AlarmUnknown describes unknown alarm code.
+	AlarmUnknown
+)
+
+// DetectorState describes a state of the smoke or natural gas detector.
+// Battery is reported only by smoke detectors.
+type DetectorState struct {
+	BatteryState
+
+	Alarm   AlarmStatus
+	Density float64
+}
+
+// Detector defines a Honeywell smoke or natural gas detector.
+type Detector struct {
+	XiaomiDevice
+
+	ID      string
+	Gateway *Gateway
+	state   *DetectorState
+}
+
+// Stops is not uses for gateway devices.
+func (d *Detector) Stop() {
+}
+
+// GetUpdateMessage returns device's state update message.
+func (d *Detector) GetUpdateMessage() *DeviceUpdateMessage {
+	d.Gateway.Lock()
+	defer d.Gateway.Unlock()
+
+	return d.updateMessage()
+}
+
+// Returns device's state update message. Gateway lock must be held.
+func (d *Detector) updateMessage() *DeviceUpdateMessage {
+	return &DeviceUpdateMessage{
+		ID:      d.ID,
+		Model:   d.model,
+		Kind:    UpdateKindState,
+		Version: d.version,
+		State:   d.stateCopy(),
+	}
+}
+
+// Returns a copy of the device state.
+func (d *Detector) stateCopy() interface{} {
+	st := *d.state
+	return &st
+}
+
+// UpdateState performs a device update.
+func (d *Detector) UpdateState() {
+	d.updateBattery(&d.state.BatteryState)
+	d.state.Density = d.GetFieldValueFloat64(fieldDensity, d.state.Density)
+
+	if "" == d.getFieldValue(fieldAlarm) {
+		return
+	}
+
+	alarm := alarmStatus(d.GetFieldValueInt32(fieldAlarm, 0))
+	if AlarmActive == alarm && AlarmActive != d.state.Alarm {
+		d.addEvent(EventAlarm)
+	} else if AlarmActive != alarm && AlarmActive == d.state.Alarm {
+		d.addEvent(EventAlarmCleared)
+	}
+
+	d.state.Alarm = alarm
+}
+
+// SelfTest starts the detector self-test.
+func (d *Detector) SelfTest() error {
+	data := map[string]interface{}{
+		fieldSelftest.String(): "1",
+	}
+
+	return d.Gateway.write(data, d.ID, d.model)
+}
+
+// Mute silences the active alarm.
+func (d *Detector) Mute() error {
+	data := map[string]interface{}{
+		fieldMute.String(): "1",
+	}
+
+	return d.Gateway.write(data, d.ID, d.model)
+}
+
+// Converts reported alarm code.
+func alarmStatus(code int32) AlarmStatus {
+	switch code {
+	case 0:
+		return AlarmNone
+	case 1:
+		return AlarmActive
+	case 2:
+		return AlarmTest
+	case 8:
+		return AlarmBatteryFault
+	case 64:
+		return AlarmSensorFault
+	case 32768:
+		return AlarmCommunicationFault
+	default:
+		return AlarmUnknown
+	}
+}
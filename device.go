@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net"
 	"reflect"
 	"strconv"
 	"strings"
@@ -23,23 +24,44 @@ const (
 // IDevice defines Xiaomi device.
 type IDevice interface {
 	Stop()
+	Online() bool
+	LastSeen() time.Time
 	GetUpdateMessage() *DeviceUpdateMessage
 	SetRawState(map[string]interface{})
 	UpdateState()
 }
 
+// Internal device functionality, provided by XiaomiDevice.
+type internalDevice interface {
+	IDevice
+	getRawState() map[string]interface{}
+	getAvailability() *availability
+	popEvents() []*Event
+	bumpVersion()
+	stateCopy() interface{}
+	updateMessage() *DeviceUpdateMessage
+}
+
 // XiaomiDevice represents Xiaomi device.
 type XiaomiDevice struct {
 	sync.Mutex
 
-	conn   *connection
-	crypto packet.Crypto
+	conn      *connection
+	crypto    packet.Crypto
+	localAddr *net.UDPAddr
 
 	token    string
 	tokenB   []byte
 	deviceID string
+	model    string
 	rawState map[string]interface{}
 	messages chan interface{}
+	done     chan struct{}
+	events   []*Event
+	version  uint64
+
+	avail   availability
+	cmdLock sync.Mutex
 
 	lastDiscovery time.Time
 }
@@ -49,14 +71,20 @@ func (d *XiaomiDevice) SetRawState(state map[string]interface{}) {
 	d.rawState = state
 }
 
+// Returns raw state of the device.
+func (d *XiaomiDevice) getRawState() map[string]interface{} {
+	return d.rawState
+}
+
 // Starts listeners.
 func (d *XiaomiDevice) start(deviceIP, token string, port int) error {
-	c, err := newConnection(deviceIP, port)
+	c, err := newConnection(deviceIP, port, d.localAddr)
 	if err != nil {
 		return err
 	}
 
 	d.messages = make(chan interface{}, 100)
+	d.done = make(chan struct{})
 	d.conn = c
 	if "" != token {
 		d.token = token
@@ -72,16 +100,36 @@ func (d *XiaomiDevice) start(deviceIP, token string, port int) error {
 }
 
 // Stops listeners.
+// Waits for the running command, so nothing is sent to the closed connection.
+// Messages channel is left open, since producers may still run, consumers exit on done.
 func (d *XiaomiDevice) stop() {
+	d.cmdLock.Lock()
+	defer d.cmdLock.Unlock()
+
 	if nil != d.conn {
-		close(d.messages)
+		close(d.done)
 		d.conn.Close()
 	}
 }
 
+// Returns the next internal message or false if device was stopped.
+func (d *XiaomiDevice) nextMessage() (interface{}, bool) {
+	select {
+	case msg := <-d.messages:
+		return msg, true
+	case <-d.done:
+		return nil, false
+	}
+}
+
 // Retrieves field value from a response.
 func (d *XiaomiDevice) getFieldValue(field fldName) string {
-	v, ok := d.rawState[field.String()]
+	return d.getKeyValue(field.String())
+}
+
+// Retrieves value of the raw key from a response.
+func (d *XiaomiDevice) getKeyValue(key string) string {
+	v, ok := d.rawState[key]
 	if !ok {
 		return ""
 	}
@@ -92,8 +140,10 @@ func (d *XiaomiDevice) getFieldValue(field fldName) string {
 		return fmt.Sprintf("%0.f", v.(float64))
 	case reflect.String:
 		return v.(string)
+	case reflect.Bool:
+		return strconv.FormatBool(v.(bool))
 	default:
-		LOGGER.Warn("Unknown %s value type %s", field, reflect.TypeOf(v).Kind().String())
+		LOGGER.Warn("Unknown %s value type %s", key, reflect.TypeOf(v).Kind().String())
 		return ""
 	}
 }
@@ -150,7 +200,7 @@ func (d *XiaomiDevice) GetBatteryLevel(curVal float32) float32 {
 		return curVal
 	}
 
-	return float32(d.GetFieldValueUint32(fieldVoltage, 0)) / 33.0
+	return batteryCurveFor(d.model).percent(d.GetFieldValueUint32(fieldVoltage, 0))
 }
 
 // GetFieldPercentage returns percent field.
@@ -165,7 +215,12 @@ func (d *XiaomiDevice) GetFieldPercentage(field fldName, curVal float64) float64
 
 // GetFieldValueBool returns bool value.
 func (d *XiaomiDevice) GetFieldValueBool(field fldName, curVal bool) bool {
-	v := strings.ToLower(d.getFieldValue(field))
+	return d.getKeyValueBool(field.String(), curVal)
+}
+
+// Returns bool value of the raw key.
+func (d *XiaomiDevice) getKeyValueBool(key string, curVal bool) bool {
+	v := strings.ToLower(d.getKeyValue(key))
 	if "" == v {
 		return curVal
 	}
@@ -177,7 +232,15 @@ func (d *XiaomiDevice) GetFieldValueBool(field fldName, curVal bool) bool {
 }
 
 // Sends the command to a device. Will try to retry.
-func (d *XiaomiDevice) sendCommand(cmd string, data []interface{}, storeResponse bool, retries int) bool {
+// Data is either a list or an object of command params.
+func (d *XiaomiDevice) sendCommand(cmd string, data interface{}, storeResponse bool, retries int) bool {
+	d.cmdLock.Lock()
+	defer d.cmdLock.Unlock()
+
+	if d.stopped() {
+		return false
+	}
+
 	resp := false
 	for ii := 0; ii < retries; ii++ {
 		resp = d.doCommand(cmd, data, storeResponse)
@@ -190,7 +253,7 @@ func (d *XiaomiDevice) sendCommand(cmd string, data []interface{}, storeResponse
 }
 
 // Performs single command execution.
-func (d *XiaomiDevice) doCommand(cmd string, data []interface{}, storeResponse bool) bool {
+func (d *XiaomiDevice) doCommand(cmd string, data interface{}, storeResponse bool) bool {
 	if d.lastDiscovery.Add(1 * time.Minute).Before(time.Now()) {
 		if false == d.discovery() {
 			return false
@@ -221,6 +284,28 @@ func (d *XiaomiDevice) doCommand(cmd string, data []interface{}, storeResponse b
 	return d.sendAndWait(p, cmd, storeResponse)
 }
 
+// Pings the device.
+func (d *XiaomiDevice) ping() bool {
+	d.cmdLock.Lock()
+	defer d.cmdLock.Unlock()
+
+	if d.stopped() {
+		return false
+	}
+
+	return d.discovery()
+}
+
+// Checks whether device was stopped.
+func (d *XiaomiDevice) stopped() bool {
+	select {
+	case <-d.done:
+		return true
+	default:
+		return false
+	}
+}
+
 // Handles discovery request-response.
 func (d *XiaomiDevice) discovery() bool {
 	d.conn.outMessages <- packet.NewHello().Serialize()
@@ -255,8 +340,6 @@ func (d *XiaomiDevice) discovery() bool {
 			return false
 		}
 	}
-
-	return false
 }
 
 // Sends a command and waits for a response.
@@ -304,6 +387,4 @@ func (d *XiaomiDevice) sendAndWait(p *packet.Packet, cmd string, storeResponse b
 			return false
 		}
 	}
-
-	return false
 }
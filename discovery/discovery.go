@@ -39,7 +39,7 @@ func (d *discovery) start() {
 				LOGGER.Info("ID: %s", id)
 			}
 			for _, v := range entry.Text {
-				LOGGER.Info(v)
+				LOGGER.Info("%s", v)
 			}
 
 			for _, v := range entry.AddrIPv4 {
@@ -19,6 +19,29 @@ const (
 	cmdDock      = "app_charge"
 	cmdFindMe    = "find_me"
 	cmdFanPower  = "set_custom_mode"
+
+	cmdStartJoin     = "start_zigbee_join"
+	cmdRemoveDevice  = "remove_device"
+	cmdGetDeviceProp = "get_device_prop"
+
+	cmdInfo             = "miIO.info"
+	cmdGetNightLight    = "get_night_light_rgb"
+	cmdSetNightLight    = "set_night_light_rgb"
+	cmdGetArming        = "get_arming"
+	cmdSetArming        = "set_arming"
+	cmdGetIllumination  = "get_illumination"
+	cmdGetRadio         = "get_prop_fm"
+	cmdPlayRadio        = "play_fm"
+	cmdSetRadioVolume   = "volume_ctrl_fm"
+	cmdSetRadioChannel  = "play_specify_fm"
+	cmdGetRadioChannels = "get_channels"
+
+	valueOn     = "on"
+	valueOff    = "off"
+	valueToggle = "toggle"
+	valueOpen   = "open"
+	valueClose  = "close"
+	valueStop   = "stop"
 )
 
 // Gateway device model.
@@ -30,8 +53,60 @@ const (
 	devSensorHT
 	devMagnet
 	devMotion
+	devGeneric
+	devWallSwitch
+	devPlug
+	devWaterLeak
+	devSmoke
+	devNatgas
+	devCube
+	devVibration
+	devCurtain
+	devRemote
+	devLock
 )
 
+var (
+	// Model names which differ from the gateway device model names.
+	gatewayModelAliases = map[string]gatewayDeviceModel{
+		"gateway.v3":         devGateway,
+		"acpartner.v3":       devGateway,
+		"ctrl_neutral1":      devWallSwitch,
+		"ctrl_neutral2":      devWallSwitch,
+		"ctrl_ln1":           devWallSwitch,
+		"ctrl_ln2":           devWallSwitch,
+		"86plug":             devPlug,
+		"ctrl_86plug":        devPlug,
+		"ctrl_86plug.aq1":    devPlug,
+		"sensor_wleak.aq1":   devWaterLeak,
+		"sensor_cube":        devCube,
+		"sensor_cube.aqgl01": devCube,
+		"weather.v1":         devSensorHT,
+		"sensor_motion.aq2":  devMotion,
+		"86sw1":              devRemote,
+		"86sw2":              devRemote,
+		"sensor_86sw1":       devRemote,
+		"sensor_86sw2":       devRemote,
+		"remote.b186acn01":   devRemote,
+		"remote.b286acn01":   devRemote,
+		"lock.aq1":           devLock,
+	}
+)
+
+// Resolves gateway device model. Unknown models are handled as generic devices.
+func parseGatewayDeviceModel(model string) gatewayDeviceModel {
+	if mod, ok := gatewayModelAliases[model]; ok {
+		return mod
+	}
+
+	mod, err := gatewayDeviceModelString(model)
+	if err != nil {
+		return devGeneric
+	}
+
+	return mod
+}
+
 // Field names.
 type fldName int
 
@@ -42,22 +117,31 @@ const (
 	fieldVoltage
 	fieldStatus
 	fieldNoMotion
-)
-
-// ClickType represents Xiaomi switch state.
-type ClickType int
-
-const (
-	// ClickNo describes no click.
-	ClickNo ClickType = iota
-	// ClickSingle describes single click.
-	ClickSingle
-	// ClickDouble describes double click.
-	ClickDouble
-	// ClickLongPress describes the beginning of a long click.
-	ClickLongPress
-	// ClickLongRelease describes the end of a long click.
-	ClickLongRelease
+	fieldLoadPower
+	fieldPowerConsumed
+	fieldInuse
+	fieldAlarm
+	fieldDensity
+	fieldSelftest
+	fieldMute
+	fieldRotate
+	fieldFinalTiltAngle
+	fieldCoordination
+	fieldBedActivity
+	fieldSensitivity
+	fieldPressure
+	fieldLux
+	fieldIllumination
+	fieldCurtainLevel
+	fieldCurtainStatus
+	fieldDualChannel
+	fieldFingVerified
+	fieldPswVerified
+	fieldCardVerified
+	fieldKeyVerified
+	fieldVerifiedWrong
+	fieldMid
+	fieldVol
 )
 
 // Internal click type.
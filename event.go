@@ -0,0 +1,128 @@
+package miio
+
+import "time"
+
+// EventType defines a momentary device event.
+type EventType int
+
+// Click events of wireless remotes contain the channel number in Data.
+const (
+	// EventClick describes single click.
+	EventClick EventType = iota
+	// EventDoubleClick describes double click.
+	EventDoubleClick
+	// EventLongPress describes the beginning of a long click.
+	EventLongPress
+	// EventLongRelease describes the end of a long click.
+	EventLongRelease
+	// EventMotion describes detected motion.
+	EventMotion
+	// EventLeak describes detected water leak.
+	EventLeak
+	// EventLeakCleared describes the end of a water leak.
+	EventLeakCleared
+	// EventAlarm describes smoke or gas alarm.
+	EventAlarm
+	// EventAlarmCleared describes the end of smoke or gas alarm.
+	EventAlarmCleared
+	// EventFlip90 describes cube flip by 90 degrees.
+	EventFlip90
+	// EventFlip180 describes cube flip by 180 degrees.
+	EventFlip180
+	// EventMove describes cube move.
+	EventMove
+	// EventTapTwice describes double tap on the cube.
+	EventTapTwice
+	// EventShakeAir describes cube shake.
+	EventShakeAir
+	// EventSwing describes cube swing.
+	EventSwing
+	// EventAlert describes cube alert.
+	EventAlert
+	// EventFreeFall describes cube free fall.
+	EventFreeFall
+	// EventRotate describes cube rotation, Data contains *CubeRotation.
+	EventRotate
+	// EventVibrate describes detected vibration.
+	EventVibrate
+	// EventTilt describes detected tilt.
+	EventTilt
+	// EventBothClick describes single click on both remote buttons.
+	EventBothClick
+	// EventBothDoubleClick describes double click on both remote buttons.
+	EventBothDoubleClick
+	// EventBothLongPress describes long click on both remote buttons.
+	EventBothLongPress
+	// EventUnlock describes door lock unlock, Data contains *LockUnlock.
+	EventUnlock
+	// EventWrongAttempt describes failed unlock attempt, Data contains number of attempts.
+	EventWrongAttempt
+	// EventDeviceJoined describes a new gateway sub-device, Data contains its SID.
+	EventDeviceJoined
+)
+
+// EventPriority defines event importance.
+type EventPriority int
+
+const (
+	// PriorityNormal describes regular events.
+	PriorityNormal EventPriority = iota
+	// PriorityHigh describes safety-related events.
+	// They are never dropped for OverflowBlock and OverflowBlockHighPriority subscribers.
+	PriorityHigh
+)
+
+// Event describes a momentary device event.
+// Events are emitted once per device report and are not part of the state.
+type Event struct {
+	Type     EventType
+	Priority EventPriority
+	SID      string
+	Time     time.Time
+	// Event specific data, nil for most events.
+	Data interface{}
+}
+
+// Returns the event priority.
+func (t EventType) priority() EventPriority {
+	switch t {
+	case EventLeak, EventLeakCleared, EventAlarm, EventAlarmCleared, EventWrongAttempt:
+		return PriorityHigh
+	default:
+		return PriorityNormal
+	}
+}
+
+// Checks whether event is a momentary action rather than a state transition.
+// Momentary events are emitted only for fresh reports.
+func (t EventType) momentary() bool {
+	switch t {
+	case EventLeak, EventLeakCleared, EventAlarm, EventAlarmCleared:
+		return false
+	default:
+		return true
+	}
+}
+
+// Registers a new event.
+func (d *XiaomiDevice) addEvent(t EventType) {
+	d.addEventData(t, nil)
+}
+
+// Registers a new event with additional data.
+func (d *XiaomiDevice) addEventData(t EventType, data interface{}) {
+	d.events = append(d.events, &Event{
+		Type:     t,
+		Priority: t.priority(),
+		SID:      d.deviceID,
+		Time:     time.Now(),
+		Data:     data,
+	})
+}
+
+// Returns registered events and clears the queue.
+func (d *XiaomiDevice) popEvents() []*Event {
+	events := d.events
+	d.events = nil
+	return events
+}
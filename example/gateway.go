@@ -24,8 +24,9 @@ func main() {
 		LOGGER.Fatal("%s", err.Error())
 	}
 
+	sub := g.Subscribe(nil, 50, miio.OverflowDropOldest)
 	go func() {
-		for msg := range g.UpdateChan {
+		for msg := range sub.C {
 			LOGGER.Info("ID: %s, State: %+v", msg.ID, msg.State)
 		}
 	}()
@@ -34,5 +35,20 @@ func main() {
 	g.SetBrightness(59)
 	g.SetColor(color.RGBA{R: 128, G: 100, B: 24, A: 0})
 
+	for _, d := range g.Devices() {
+		switch sd := g.SubDevice(d.ID).(type) {
+		case *miio.WallSwitch:
+			err = sd.Toggle(0)
+		case *miio.Plug:
+			err = sd.On()
+		default:
+			continue
+		}
+
+		if err != nil {
+			LOGGER.Error("Failed to control %s: %s", d.ID, err.Error())
+		}
+	}
+
 	time.Sleep(10 * time.Second)
 }
@@ -25,11 +25,10 @@ func main() {
 		LOGGER.Fatal("%s", err.Error())
 	}
 
-	go func() {
-		for msg := range v.UpdateChan {
+	v.SubscribeFunc(&miio.UpdateFilter{Kinds: []miio.UpdateKind{miio.UpdateKindState}}, 0, miio.OverflowDropOldest,
+		func(msg *miio.DeviceUpdateMessage) {
 			LOGGER.Info("%+v", msg.State)
-		}
-	}()
+		})
 
 	v.UpdateStatus()
 	time.Sleep(2 * time.Second)
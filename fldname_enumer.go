@@ -6,9 +6,9 @@ import (
 	"fmt"
 )
 
-const _fldNameName = "rgbtemperaturehumidityvoltagestatusno_motion"
+const _fldNameName = "rgbtemperaturehumidityvoltagestatusno_motionload_powerpower_consumedinusealarmdensityselftestmuterotatefinal_tilt_anglecoordinationbed_activitysensitivitypressureluxilluminationcurtain_levelcurtain_statusdual_channelfing_verifiedpsw_verifiedcard_verifiedkey_verifiedverified_wrongmidvol"
 
-var _fldNameIndex = [...]uint8{0, 3, 14, 22, 29, 35, 44}
+var _fldNameIndex = [...]uint16{0, 3, 14, 22, 29, 35, 44, 54, 68, 73, 78, 85, 93, 97, 103, 119, 131, 143, 154, 162, 165, 177, 190, 204, 216, 229, 241, 254, 266, 280, 283, 286}
 
 func (i fldName) String() string {
 	if i < 0 || i >= fldName(len(_fldNameIndex)-1) {
@@ -17,15 +17,40 @@ func (i fldName) String() string {
 	return _fldNameName[_fldNameIndex[i]:_fldNameIndex[i+1]]
 }
 
-var _fldNameValues = []fldName{0, 1, 2, 3, 4, 5}
+var _fldNameValues = []fldName{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30}
 
 var _fldNameNameToValueMap = map[string]fldName{
-	_fldNameName[0:3]:   0,
-	_fldNameName[3:14]:  1,
-	_fldNameName[14:22]: 2,
-	_fldNameName[22:29]: 3,
-	_fldNameName[29:35]: 4,
-	_fldNameName[35:44]: 5,
+	_fldNameName[0:3]:     0,
+	_fldNameName[3:14]:    1,
+	_fldNameName[14:22]:   2,
+	_fldNameName[22:29]:   3,
+	_fldNameName[29:35]:   4,
+	_fldNameName[35:44]:   5,
+	_fldNameName[44:54]:   6,
+	_fldNameName[54:68]:   7,
+	_fldNameName[68:73]:   8,
+	_fldNameName[73:78]:   9,
+	_fldNameName[78:85]:   10,
+	_fldNameName[85:93]:   11,
+	_fldNameName[93:97]:   12,
+	_fldNameName[97:103]:  13,
+	_fldNameName[103:119]: 14,
+	_fldNameName[119:131]: 15,
+	_fldNameName[131:143]: 16,
+	_fldNameName[143:154]: 17,
+	_fldNameName[154:162]: 18,
+	_fldNameName[162:165]: 19,
+	_fldNameName[165:177]: 20,
+	_fldNameName[177:190]: 21,
+	_fldNameName[190:204]: 22,
+	_fldNameName[204:216]: 23,
+	_fldNameName[216:229]: 24,
+	_fldNameName[229:241]: 25,
+	_fldNameName[241:254]: 26,
+	_fldNameName[254:266]: 27,
+	_fldNameName[266:280]: 28,
+	_fldNameName[280:283]: 29,
+	_fldNameName[283:286]: 30,
 }
 
 // fldNameString retrieves an enum value from the enum constants string name.
@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"image/color"
 	"net"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -15,13 +18,19 @@ const (
 )
 
 var (
+	multicastIP = net.IPv4(224, 0, 0, 50)
+
 	iv = []byte{0x17, 0x99, 0x6d, 0x09, 0x3d, 0x28, 0xdd, 0xb3, 0xba, 0x69, 0x5a, 0x2e, 0x6f, 0x58, 0x56, 0x2e}
 )
 
 // DeviceUpdateMessage contains data about an update.
+// State is a copy and is safe to use from any goroutine.
 type DeviceUpdateMessage struct {
-	ID    string
-	State interface{}
+	ID      string
+	Model   string
+	Kind    UpdateKind
+	Version uint64
+	State   interface{}
 }
 
 // GatewayState defines the gateway state.
@@ -29,6 +38,8 @@ type GatewayState struct {
 	On         bool
 	RGB        color.Color
 	Brightness uint8
+	// Illuminance, lux.
+	Illuminance uint32
 
 	internalRGB uint32
 }
@@ -36,31 +47,57 @@ type GatewayState struct {
 // Gateway represents a Xiaomi gateway.
 type Gateway struct {
 	XiaomiDevice
+	*UpdateBus
 	multiCast *net.UDPConn
 	aesKey    []byte
+	ip        net.IP
+	opts      *GatewayOptions
+	connLock  sync.RWMutex
+	ownMCast  bool
+
+	state *GatewayState
 
-	State      *GatewayState
-	UpdateChan chan *DeviceUpdateMessage
+	devices map[string]internalDevice
 
-	Sensors  map[string]*SensorHT
-	Magnets  map[string]*Magnet
-	Motions  map[string]*Motion
-	Switches map[string]*Switch
+	writeLock    sync.Mutex
+	ackLock      sync.Mutex
+	acks         map[string]chan *command
+	tokenLock    sync.Mutex
+	tokenUpdated chan struct{}
+	protoVersion int32
 }
 
 // NewGateway creates a new gateway.
 func NewGateway(deviceIP, aesKey string) (*Gateway, error) {
+	return newGateway(deviceIP, aesKey, nil, true)
+}
+
+// NewGatewayWithOptions creates a new gateway with custom connection options.
+func NewGatewayWithOptions(deviceIP, aesKey string, opts *GatewayOptions) (*Gateway, error) {
+	return newGateway(deviceIP, aesKey, opts, true)
+}
+
+// Creates a new gateway, optionally with its own multi-cast listener.
+func newGateway(deviceIP, aesKey string, opts *GatewayOptions, multiCast bool) (*Gateway, error) {
+	local, err := opts.localAddr()
+	if err != nil {
+		return nil, err
+	}
+
 	g := &Gateway{
-		UpdateChan: make(chan *DeviceUpdateMessage, 50),
-		State:      &GatewayState{RGB: color.RGBA{R: 0, G: 0, B: 0, A: 0}},
-		Sensors:    make(map[string]*SensorHT),
-		Magnets:    make(map[string]*Magnet),
-		Motions:    make(map[string]*Motion),
-		Switches:   make(map[string]*Switch),
-		aesKey:     []byte(aesKey),
+		UpdateBus:    NewUpdateBus(),
+		state:        &GatewayState{RGB: color.RGBA{R: 0, G: 0, B: 0, A: 0}},
+		devices:      make(map[string]internalDevice),
+		acks:         make(map[string]chan *command),
+		tokenUpdated: make(chan struct{}),
+		aesKey:       []byte(aesKey),
+		ip:           net.ParseIP(deviceIP),
+		opts:         opts,
 	}
+	g.localAddr = local
+	g.avail.setTimeout(opts.gatewayTimeout())
 
-	err := g.start(deviceIP, "", gatewayPort)
+	err = g.start(deviceIP, "", opts.port())
 	if err != nil {
 		g.stop()
 		return nil, err
@@ -72,35 +109,69 @@ func NewGateway(deviceIP, aesKey string) (*Gateway, error) {
 		return nil, err
 	}
 
-	err = g.startMultiCast()
-	if err != nil {
-		g.stop()
-		return nil, err
+	g.ownMCast = multiCast && opts.multiCastEnabled()
+	if g.ownMCast {
+		err = g.startMultiCast(opts)
+		if err != nil {
+			g.stop()
+			return nil, err
+		}
 	}
 
 	go g.processMessages()
-	go g.processGatewayMessages()
+	go g.processGatewayMessages(g.conn)
+	go g.watchAvailability()
+	go g.watchdog()
+	if opts.pollInterval() > 0 {
+		go g.poll(opts.pollInterval())
+	}
+
 	return g, nil
 }
 
 // GetUpdateMessage returns a state update message.
 func (g *Gateway) GetUpdateMessage() *DeviceUpdateMessage {
+	g.Lock()
+	defer g.Unlock()
+
+	return g.updateMessage()
+}
+
+// Returns a state update message. Gateway lock must be held.
+func (g *Gateway) updateMessage() *DeviceUpdateMessage {
 	return &DeviceUpdateMessage{
-		State: g.State,
-		ID:    g.deviceID,
+		ID:      g.deviceID,
+		Model:   devGateway.String(),
+		Kind:    UpdateKindState,
+		Version: g.version,
+		State:   g.stateCopy(),
 	}
 }
 
+// Returns a copy of the gateway state.
+func (g *Gateway) stateCopy() interface{} {
+	st := *g.state
+	return &st
+}
+
+// Returns a copy of the gateway state, taking the lock.
+func (g *Gateway) currentState() GatewayState {
+	g.Lock()
+	defer g.Unlock()
+
+	return *g.state
+}
+
 // SetColor sets the LED light color.
 func (g *Gateway) SetColor(c color.Color) error {
 	r, gC, b, _ := c.RGBA()
-	_, _, _, a := g.State.RGB.RGBA()
+	_, _, _, a := g.currentState().RGB.RGBA()
 
 	corrected := color.RGBA{
-		R: uint8(r/256),
-		G: uint8(gC/256),
-		B: uint8(b/256),
-		A: uint8(a/256),
+		R: uint8(r / 256),
+		G: uint8(gC / 256),
+		B: uint8(b / 256),
+		A: uint8(a / 256),
 	}
 
 	data := map[string]interface{}{
@@ -119,13 +190,13 @@ func (g *Gateway) SetBrightness(br uint8) error {
 		return g.Off()
 	}
 
-	r, gC, b, _ := g.State.RGB.RGBA()
+	r, gC, b, _ := g.currentState().RGB.RGBA()
 
 	corrected := color.RGBA{
-		R: uint8(r/256),
-		G: uint8(gC/256),
-		B: uint8(b/256),
-		A: 100 -br,
+		R: uint8(r / 256),
+		G: uint8(gC / 256),
+		B: uint8(b / 256),
+		A: 100 - br,
 	}
 
 	data := map[string]interface{}{
@@ -137,7 +208,7 @@ func (g *Gateway) SetBrightness(br uint8) error {
 
 // On turns the gateway on.
 func (g *Gateway) On() error {
-	if !g.State.On {
+	if !g.currentState().On {
 		return g.SetColor(color.RGBA{R: 255, G: 255, B: 255, A: 0})
 	}
 
@@ -146,7 +217,7 @@ func (g *Gateway) On() error {
 
 // Off turns the gateway off.
 func (g *Gateway) Off() error {
-	if g.State.On {
+	if g.currentState().On {
 		return g.SetColor(color.RGBA{R: 0, G: 0, B: 0, A: 0})
 	}
 
@@ -155,41 +226,62 @@ func (g *Gateway) Off() error {
 
 // Stop stops the gateway.
 func (g *Gateway) Stop() {
+	g.connLock.Lock()
 	if nil != g.multiCast {
 		g.multiCast.Close()
+		g.multiCast = nil
 	}
+
 	g.stop()
-	close(g.UpdateChan)
+	g.connLock.Unlock()
+
+	g.close()
 }
 
 // UpdateState updates the gateway state.
 func (g *Gateway) UpdateState() {
-	g.State.internalRGB = g.GetFieldValueUint32(fieldRGB, g.State.internalRGB)
+	g.state.internalRGB = g.GetFieldValueUint32(fieldRGB, g.state.internalRGB)
 
-	if g.State.internalRGB > 0 {
-		g.State.On = true
-		g.State.RGB = rgbFromUint32(g.State.internalRGB)
-		_, _, _, a := g.State.RGB.RGBA()
+	if g.state.internalRGB > 0 {
+		g.state.On = true
+		g.state.RGB = rgbFromUint32(g.state.internalRGB)
+		_, _, _, a := g.state.RGB.RGBA()
 		a = a / 256
 
 		if a > 100 {
 			a = 100
 		}
 
-		g.State.Brightness = uint8(100 - a)
+		g.state.Brightness = uint8(100 - a)
 	} else {
-		g.State.On = false
+		g.state.On = false
+	}
+
+	if "" != g.getFieldValue(fieldIllumination) {
+		g.state.Illuminance = illuminanceLux(g.GetFieldValueUint32(fieldIllumination, 0))
 	}
 }
 
 // Requests a list of connected devices.
+// Until protocol version is known, both v1 and v2 requests are sent.
 func (g *Gateway) getDevices() error {
-	return g.command(cmdGetDevices, map[string]interface{}{})
+	err := g.command(cmdGetDevices, map[string]interface{}{})
+	if err != nil || protoUnknown != g.protocol() {
+		return err
+	}
+
+	return g.send(&command{Cmd: cmdGetDevicesV2})
 }
 
 // Processes incoming messages.
+// Messages are handled one by one, so updates and events are published in the order of reports.
 func (g *Gateway) processMessages() {
-	for msg := range g.messages {
+	for {
+		msg, ok := g.nextMessage()
+		if !ok {
+			return
+		}
+
 		m := msg.(*command)
 		switch m.Cmd {
 		case cmdGetDevices:
@@ -197,9 +289,11 @@ func (g *Gateway) processMessages() {
 			g.processHandShake(m)
 			g.command(cmdGetDeviceState, map[string]interface{}{})
 		case cmdGetDeviceState, cmdDeviceReport, cmdSetDeviceState:
-			go g.processDeviceState(m)
-		case cmdHandShake, cmdHeartBeat:
-			go g.processHandShake(m)
+			g.processDeviceState(m)
+		case cmdHandShake:
+			g.processHandShake(m)
+		case cmdHeartBeat:
+			g.processHeartBeat(m)
 		}
 	}
 }
@@ -207,7 +301,21 @@ func (g *Gateway) processMessages() {
 // Processes handshake response.
 func (g *Gateway) processHandShake(cmd *command) {
 	if "" != cmd.Token {
-		g.token = cmd.Token
+		g.setToken(cmd.Token)
+	}
+}
+
+// Processes heartbeat message.
+// Sub-devices send their full state along with a heartbeat.
+func (g *Gateway) processHeartBeat(cmd *command) {
+	if devGateway != parseGatewayDeviceModel(cmd.Model) {
+		g.processDeviceState(cmd)
+		return
+	}
+
+	g.processHandShake(cmd)
+	if g.avail.seen() {
+		g.publish(g.availabilityMessage(g.sid(), devGateway.String()))
 	}
 }
 
@@ -227,148 +335,316 @@ func (g *Gateway) processDiscovery(data string) {
 
 // Processes a device state message.
 func (g *Gateway) processDeviceState(cmd *command) {
+	for _, msg := range g.updateDeviceState(cmd) {
+		g.publish(msg)
+	}
+}
+
+// Updates a device state and returns resulting update messages.
+func (g *Gateway) updateDeviceState(cmd *command) []*DeviceUpdateMessage {
 	g.Lock()
 	defer g.Unlock()
 
-	mod, err := gatewayDeviceModelString(cmd.Model)
-	if err != nil {
-		LOGGER.Error("Unknown device type: %s", cmd.Model)
-		return
-	}
-
+	mod := parseGatewayDeviceModel(cmd.Model)
 	data := make(map[string]interface{})
-	err = json.Unmarshal([]byte(cmd.Data), &data)
+	err := json.Unmarshal([]byte(cmd.Data), &data)
 	if err != nil {
 		LOGGER.Error("Failed to un-marshal device data: %s", err.Error())
-		return
+		return nil
 	}
 
-	var device IDevice
-
-	switch mod {
-	case devGateway:
+	var device internalDevice
+	if devGateway == mod {
 		device = g
-	case devSensorHT:
-		d, ok := g.Sensors[cmd.Sid]
+	} else {
+		d, ok := g.devices[cmd.Sid]
 		if !ok {
-			d = &SensorHT{
-				State:   &SensorHTState{},
-				Gateway: g,
-				ID:      cmd.Sid,
+			d = g.newSubDevice(mod, cmd.Model, cmd.Sid)
+			if nil == d {
+				LOGGER.Warn("Unsupported device type: %s", cmd.Model)
+				return nil
 			}
-			g.Sensors[cmd.Sid] = d
+
+			g.devices[cmd.Sid] = d
 		}
 		device = d
+	}
+
+	before := device.stateCopy()
+	device.SetRawState(data)
+	device.UpdateState()
+	unchanged := isUnchanged(before, device.stateCopy())
+	if !unchanged {
+		device.bumpVersion()
+	}
+
+	msg := device.updateMessage()
+	msgs := make([]*DeviceUpdateMessage, 0)
+
+	a := device.getAvailability()
+	if devGateway == mod {
+		a.setTimeout(g.opts.gatewayTimeout())
+	} else {
+		a.setTimeout(availabilityTimeout(mod))
+	}
+	if a.seen() {
+		msgs = append(msgs, &DeviceUpdateMessage{
+			ID:      msg.ID,
+			Model:   msg.Model,
+			Kind:    UpdateKindAvailability,
+			Version: msg.Version,
+			State:   a.state(),
+		})
+	}
+
+	if !unchanged {
+		msgs = append(msgs, msg)
+	}
+
+	// Momentary events are only valid for fresh reports, not for re-reads.
+	for _, e := range device.popEvents() {
+		if cmdDeviceReport != cmd.Cmd && e.Type.momentary() {
+			continue
+		}
+
+		msgs = append(msgs, &DeviceUpdateMessage{
+			ID:      msg.ID,
+			Model:   msg.Model,
+			Kind:    UpdateKindEvent,
+			Version: msg.Version,
+			State:   e,
+		})
+	}
+
+	return msgs
+}
+
+// Creates a new sub-device of the given model.
+func (g *Gateway) newSubDevice(mod gatewayDeviceModel, model, sid string) internalDevice {
+	switch mod {
+	case devSensorHT:
+		return &SensorHT{
+			XiaomiDevice: XiaomiDevice{deviceID: sid, model: model},
+			state:        &SensorHTState{},
+			Gateway:      g,
+			ID:           sid,
+		}
 	case devMagnet:
-		d, ok := g.Magnets[cmd.Sid]
-		if !ok {
-			d = &Magnet{
-				State:   &MagnetState{},
-				Gateway: g,
-				ID:      cmd.Sid,
-			}
-			g.Magnets[cmd.Sid] = d
+		return &Magnet{
+			XiaomiDevice: XiaomiDevice{deviceID: sid, model: model},
+			state:        &MagnetState{},
+			Gateway:      g,
+			ID:           sid,
 		}
-		device = d
 	case devMotion:
-		d, ok := g.Motions[cmd.Sid]
-		if !ok {
-			d = &Motion{
-				State:   &MotionState{},
-				Gateway: g,
-				ID:      cmd.Sid,
-			}
-			g.Motions[cmd.Sid] = d
+		return &Motion{
+			XiaomiDevice: XiaomiDevice{deviceID: sid, model: model},
+			state:        &MotionState{},
+			Gateway:      g,
+			ID:           sid,
 		}
-		device = d
 	case devSwitch:
-		d, ok := g.Switches[cmd.Sid]
-		if !ok {
-			d = &Switch{
-				State:   &SwitchState{},
-				Gateway: g,
-				ID:      cmd.Sid,
-			}
-			g.Switches[cmd.Sid] = d
+		return &Switch{
+			XiaomiDevice: XiaomiDevice{deviceID: sid, model: model},
+			state:        &SwitchState{},
+			Gateway:      g,
+			ID:           sid,
+		}
+	case devWallSwitch:
+		return &WallSwitch{
+			XiaomiDevice: XiaomiDevice{deviceID: sid, model: model},
+			state:        &WallSwitchState{Channels: wallSwitchChannels(model)},
+			Gateway:      g,
+			ID:           sid,
+		}
+	case devPlug:
+		return &Plug{
+			XiaomiDevice: XiaomiDevice{deviceID: sid, model: model},
+			state:        &PlugState{},
+			Gateway:      g,
+			ID:           sid,
+		}
+	case devWaterLeak:
+		return &WaterLeak{
+			XiaomiDevice: XiaomiDevice{deviceID: sid, model: model},
+			state:        &WaterLeakState{},
+			Gateway:      g,
+			ID:           sid,
+		}
+	case devSmoke, devNatgas:
+		return &Detector{
+			XiaomiDevice: XiaomiDevice{deviceID: sid, model: model},
+			state:        &DetectorState{},
+			Gateway:      g,
+			ID:           sid,
+		}
+	case devCube:
+		return &Cube{
+			XiaomiDevice: XiaomiDevice{deviceID: sid, model: model},
+			state:        &CubeState{},
+			Gateway:      g,
+			ID:           sid,
+		}
+	case devVibration:
+		return &Vibration{
+			XiaomiDevice: XiaomiDevice{deviceID: sid, model: model},
+			state:        &VibrationState{},
+			Gateway:      g,
+			ID:           sid,
+		}
+	case devCurtain:
+		return &Curtain{
+			XiaomiDevice: XiaomiDevice{deviceID: sid, model: model},
+			state:        &CurtainState{},
+			Gateway:      g,
+			ID:           sid,
+		}
+	case devRemote:
+		return &Remote{
+			XiaomiDevice: XiaomiDevice{deviceID: sid, model: model},
+			state:        &RemoteState{Channels: remoteChannels(model)},
+			Gateway:      g,
+			ID:           sid,
+		}
+	case devLock:
+		return &Lock{
+			XiaomiDevice: XiaomiDevice{deviceID: sid, model: model},
+			state:        &LockState{},
+			Gateway:      g,
+			ID:           sid,
+		}
+	case devGeneric:
+		LOGGER.Info("Unknown device type %s, using generic device", model)
+		return &GenericSubDevice{
+			XiaomiDevice: XiaomiDevice{deviceID: sid, model: model},
+			state:        &GenericSubDeviceState{Data: make(map[string]interface{})},
+			Gateway:      g,
+			ID:           sid,
 		}
-		device = d
-	default:
-		LOGGER.Warn("Unsupported device type: %s", cmd.Model)
-		return
 	}
 
-	device.SetRawState(data)
-	device.UpdateState()
-	g.UpdateChan <- device.GetUpdateMessage()
+	return nil
 }
 
-// Starts multi-cast listener.
-func (g *Gateway) startMultiCast() error {
-	l, err := net.ListenMulticastUDP("udp4", nil, &net.UDPAddr{
-		IP:   net.IPv4(224, 0, 0, 50),
-		Port: gatewayPort,
-	})
+// Periodically checks availability of the gateway and its devices.
+func (g *Gateway) watchAvailability() {
+	ticker := time.NewTicker(availabilityCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.done:
+			return
+		case <-ticker.C:
+		}
+
+		for _, msg := range g.checkAvailability() {
+			g.publish(msg)
+		}
+	}
+}
+
+// Returns availability messages for devices which went offline.
+func (g *Gateway) checkAvailability() []*DeviceUpdateMessage {
+	g.Lock()
+	defer g.Unlock()
+
+	devices := map[string]internalDevice{g.deviceID: g}
+	for k, v := range g.devices {
+		devices[k] = v
+	}
+
+	msgs := make([]*DeviceUpdateMessage, 0)
+	for id, d := range devices {
+		a := d.getAvailability()
+		if a.check() {
+			msg := d.updateMessage()
+			msgs = append(msgs, &DeviceUpdateMessage{
+				ID:      id,
+				Model:   msg.Model,
+				Kind:    UpdateKindAvailability,
+				Version: msg.Version,
+				State:   a.state(),
+			})
+		}
+	}
+
+	return msgs
+}
 
+// Starts multi-cast listener.
+func (g *Gateway) startMultiCast(opts *GatewayOptions) error {
+	l, err := opts.listenMultiCast()
 	if err != nil {
 		return err
 	}
 
 	g.multiCast = l
-	go g.listenMultiCast()
+	go g.listenMultiCast(l)
 	return nil
 }
 
 // Listens for a multi-cast messages.
-func (g *Gateway) listenMultiCast() {
+func (g *Gateway) listenMultiCast(l *net.UDPConn) {
 	buf := make([]byte, 2048)
 	for {
-		size, _, err := g.multiCast.ReadFromUDP(buf)
+		size, addr, err := l.ReadFromUDP(buf)
 		if err != nil {
 			LOGGER.Error("Error reading from MultiCast: %s", err.Error())
 			return
 		}
 
 		if size > 0 {
+			if !addr.IP.Equal(g.ip) {
+				LOGGER.Debug("Ignoring message from another gateway %s", addr.IP.String())
+				continue
+			}
+
 			LOGGER.Debug("Received device message: %s", string(buf[0:size]))
 			msg := make([]byte, size)
 			copy(msg, buf[0:size])
-			g.conn.DeviceMessages <- msg
+			g.handlePacket(msg)
 		}
 	}
 }
 
-// Requests a device state.
+// Sets the gateway state.
 func (g *Gateway) stateCommand(data map[string]interface{}) error {
-	return g.commandWithSid(cmdSetDeviceState, data, g.deviceID)
+	return g.write(data, g.sid(), devGateway.String())
 }
 
 // Performs a device command.
 func (g *Gateway) command(cmd string, data map[string]interface{}) error {
-	return g.commandWithSid(cmd, data, g.deviceID)
+	return g.commandWithSid(cmd, data, g.sid())
 }
 
 // Performs a device command with specific SID.
 func (g *Gateway) commandWithSid(cmd string, data map[string]interface{}, sid string) error {
+	return g.commandWithModel(cmd, data, sid, "")
+}
+
+// Performs a device command with specific SID and model.
+func (g *Gateway) commandWithModel(cmd string, data map[string]interface{}, sid, model string) error {
 	block, err := aes.NewCipher(g.aesKey)
 	if err != nil {
 		LOGGER.Error("Failed to create CMD cipher: %s", err.Error())
 		return err
 	}
 
+	token := g.getToken()
 	mode := cipher.NewCBCEncrypter(block, iv)
-	cipherText := make([]byte, len(g.token))
-	mode.CryptBlocks(cipherText, []byte(g.token))
+	cipherText := make([]byte, len(token))
+	mode.CryptBlocks(cipherText, []byte(token))
 	command := &command{
 		deviceDTO: deviceDTO{
-			Sid: sid,
+			Sid:   sid,
+			Model: model,
 		},
 		Cmd: cmd,
 	}
 
-	data["key"] = fmt.Sprintf("%X", cipherText)
-	bytes, _ := json.Marshal(data)
-	command.Data = string(bytes)
-	err = g.conn.Send(command)
+	command.setPayload(g.protocol(), data, fmt.Sprintf("%X", cipherText))
+	err = g.send(command)
 	if err != nil {
 		LOGGER.Error("Failed to send CMD: %s", err.Error())
 		return err
@@ -378,23 +654,83 @@ func (g *Gateway) commandWithSid(cmd string, data map[string]interface{}, sid st
 }
 
 // Processes incoming gateway messages.
-func (g *Gateway) processGatewayMessages() {
-	for msg := range g.conn.DeviceMessages {
-		cmd := &command{}
-		err := json.Unmarshal(msg, cmd)
-		if err != nil {
-			LOGGER.Error("Failed to un-marshal command: %s", err.Error())
-			continue
-		}
+func (g *Gateway) processGatewayMessages(c *connection) {
+	for msg := range c.DeviceMessages {
+		g.handlePacket(msg)
+	}
+}
 
-		if len(cmd.Cmd) >= len(cmdAck) && cmd.Cmd[len(cmd.Cmd)-len(cmdAck):] == cmdAck {
-			cmd.Cmd = cmd.Cmd[:len(cmd.Cmd)-len(cmdAck)]
+// Handles a single packet received from the gateway.
+func (g *Gateway) handlePacket(msg []byte) {
+	select {
+	case <-g.done:
+		return
+	default:
+	}
+
+	cmd := &command{}
+	err := json.Unmarshal(msg, cmd)
+	if err != nil {
+		LOGGER.Error("Failed to un-marshal command: %s", err.Error())
+		return
+	}
+
+	if cmd.isV2() {
+		if protoV2 != g.protocol() {
+			LOGGER.Info("Gateway uses protocol v2")
+			atomic.StoreInt32(&g.protoVersion, protoV2)
 		}
+		cmd.toV1()
+	} else if cmdGetDevices+cmdAck == cmd.Cmd {
+		// Device list in v1 format, unless v2 was detected already.
+		atomic.CompareAndSwapInt32(&g.protoVersion, protoUnknown, protoV1)
+	}
 
-		if "" == g.deviceID {
-			g.deviceID = cmd.Sid
+	if len(cmd.Cmd) >= len(cmdAck) && cmd.Cmd[len(cmd.Cmd)-len(cmdAck):] == cmdAck {
+		cmd.Cmd = cmd.Cmd[:len(cmd.Cmd)-len(cmdAck)]
+		if cmdSetDeviceState == cmd.Cmd && !g.processWriteAck(cmd) {
+			return
 		}
+	}
+
+	// Sub-devices reports are sent with their own SIDs.
+	if cmdGetDevices == cmd.Cmd || devGateway == parseGatewayDeviceModel(cmd.Model) {
+		g.setSid(cmd.Sid)
+	}
 
-		g.messages <- cmd
+	select {
+	case g.messages <- cmd:
+	case <-g.done:
 	}
 }
+
+// Returns the gateway SID.
+func (g *Gateway) sid() string {
+	g.Lock()
+	defer g.Unlock()
+
+	return g.deviceID
+}
+
+// Sets the gateway SID, if it's not known yet.
+func (g *Gateway) setSid(sid string) {
+	g.Lock()
+	defer g.Unlock()
+
+	if "" == g.deviceID {
+		g.deviceID = sid
+	}
+}
+
+// Checks whether device is the gateway itself or is connected to it.
+func (g *Gateway) owns(sid string) bool {
+	g.Lock()
+	defer g.Unlock()
+
+	if sid == g.deviceID {
+		return true
+	}
+
+	_, ok := g.devices[sid]
+	return ok
+}
@@ -0,0 +1,460 @@
+package miio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Number of miIO command retries.
+	gwMiIORetries = 3
+	// Interval between device list checks while join is permitted.
+	joinCheckInterval = 5 * time.Second
+	// Maximum join permission duration accepted by the gateway.
+	maxJoinDuration = 255 * time.Second
+	// Number of values describing a single sub-device in the device list.
+	deviceListStride = 5
+	// Gateway own SID in miIO device properties.
+	gwMiIOSid = "lumi.0"
+	// Prefix of sub-device SIDs in miIO device properties.
+	gwMiIOSidPrefix = "lumi."
+)
+
+var (
+	// ErrMiIOCommand is returned if gateway didn't respond to a miIO command.
+	ErrMiIOCommand = errors.New("miio command failed")
+)
+
+// miIO command response.
+type miIOResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// GatewayMiIOState describes a gateway state obtained over miIO.
+type GatewayMiIOState struct {
+	NightLightOn         bool
+	NightLight           color.Color
+	NightLightBrightness uint8
+	Armed                bool
+	// Illuminance, lux.
+	Illuminance  uint32
+	RadioOn      bool
+	RadioVolume  int
+	RadioChannel int
+}
+
+// GatewayFirmware describes gateway hardware and firmware.
+type GatewayFirmware struct {
+	Model    string `json:"model"`
+	Firmware string `json:"fw_ver"`
+	Hardware string `json:"hw_ver"`
+	MAC      string `json:"mac"`
+}
+
+// RadioChannel describes FM radio channel.
+type RadioChannel struct {
+	ID  int    `json:"id"`
+	URL string `json:"url"`
+}
+
+// Radio state obtained from the gateway.
+type radioState struct {
+	Program int    `json:"current_program"`
+	Volume  int    `json:"current_volume"`
+	Status  string `json:"current_status"`
+}
+
+// Radio channels obtained from the gateway.
+type radioChannels struct {
+	Channels []*RadioChannel `json:"chs"`
+}
+
+// GatewayMiIO defines a Xiaomi gateway controlled over the miIO protocol.
+// It can be used alongside the LAN gateway API.
+type GatewayMiIO struct {
+	XiaomiDevice
+	*UpdateBus
+	State *GatewayMiIOState
+
+	callLock sync.Mutex
+}
+
+// NewGatewayMiIO creates a new miIO gateway client.
+func NewGatewayMiIO(deviceIP, token string) (*GatewayMiIO, error) {
+	g := &GatewayMiIO{
+		UpdateBus: NewUpdateBus(),
+		State:     &GatewayMiIOState{NightLight: color.RGBA{}},
+		XiaomiDevice: XiaomiDevice{
+			deviceID: deviceIP,
+			model:    devGateway.String(),
+			rawState: make(map[string]interface{}),
+		},
+	}
+
+	err := g.start(deviceIP, token, defaultPort)
+	if err != nil {
+		return nil, err
+	}
+
+	go g.processUpdates()
+	go g.monitorAvailability(g.deviceID, g.model, g.publish)
+	return g, nil
+}
+
+// Stop stops the client.
+func (g *GatewayMiIO) Stop() {
+	g.stop()
+	g.close()
+}
+
+// GetUpdateMessage returns an update message.
+func (g *GatewayMiIO) GetUpdateMessage() *DeviceUpdateMessage {
+	g.Lock()
+	defer g.Unlock()
+
+	return &DeviceUpdateMessage{
+		ID:      g.deviceID,
+		Model:   g.model,
+		Kind:    UpdateKindState,
+		Version: g.version,
+		State:   g.stateCopy(),
+	}
+}
+
+// Returns a copy of the gateway state.
+func (g *GatewayMiIO) stateCopy() interface{} {
+	st := *g.State
+	return &st
+}
+
+// UpdateState performs a state update.
+func (g *GatewayMiIO) UpdateState() {
+	err := g.UpdateStatus()
+	if err != nil {
+		LOGGER.Error("Failed to update gateway state: %s", err.Error())
+	}
+}
+
+// UpdateStatus requests the gateway state and publishes it.
+func (g *GatewayMiIO) UpdateStatus() error {
+	var light uint32
+	err := g.callFirst(cmdGetNightLight, nil, &light)
+	if err != nil {
+		return err
+	}
+
+	var arming string
+	err = g.callFirst(cmdGetArming, nil, &arming)
+	if err != nil {
+		return err
+	}
+
+	var illumination uint32
+	err = g.callFirst(cmdGetIllumination, nil, &illumination)
+	if err != nil {
+		return err
+	}
+
+	b, err := g.call(cmdGetRadio, nil)
+	if err != nil {
+		return err
+	}
+
+	radio := &radioState{}
+	err = json.Unmarshal(b, radio)
+	if err != nil {
+		return err
+	}
+
+	g.Lock()
+	g.State.NightLightOn = light > 0
+	g.State.NightLightBrightness = uint8(light >> 24)
+	g.State.NightLight = color.RGBA{R: uint8(light >> 16), G: uint8(light >> 8), B: uint8(light), A: 255}
+	g.State.Armed = valueOn == arming
+	g.State.Illuminance = illumination
+	g.State.RadioOn = "run" == radio.Status
+	g.State.RadioVolume = radio.Volume
+	g.State.RadioChannel = radio.Program
+	g.bumpVersion()
+	g.Unlock()
+
+	g.publish(g.GetUpdateMessage())
+	return nil
+}
+
+// SetNightLight sets the night light color and brightness, percent.
+// Zero brightness turns the night light off.
+func (g *GatewayMiIO) SetNightLight(c color.Color, brightness uint8) error {
+	if brightness > 100 {
+		brightness = 100
+	}
+
+	r, gC, b, _ := c.RGBA()
+	val := uint32(brightness)<<24 | (r>>8)<<16 | (gC>>8)<<8 | b>>8
+	if 0 == brightness {
+		val = 0
+	}
+
+	_, err := g.call(cmdSetNightLight, []interface{}{val})
+	return err
+}
+
+// SetArming arms or disarms the gateway alarm.
+func (g *GatewayMiIO) SetArming(armed bool) error {
+	val := valueOff
+	if armed {
+		val = valueOn
+	}
+
+	_, err := g.call(cmdSetArming, []interface{}{val})
+	return err
+}
+
+// RadioOn starts the FM radio.
+func (g *GatewayMiIO) RadioOn() error {
+	_, err := g.call(cmdPlayRadio, []interface{}{valueOn})
+	return err
+}
+
+// RadioOff stops the FM radio.
+func (g *GatewayMiIO) RadioOff() error {
+	_, err := g.call(cmdPlayRadio, []interface{}{valueOff})
+	return err
+}
+
+// SetRadioVolume sets the FM radio volume, percent.
+func (g *GatewayMiIO) SetRadioVolume(volume uint8) error {
+	if volume > 100 {
+		volume = 100
+	}
+
+	_, err := g.call(cmdSetRadioVolume, []interface{}{fmt.Sprintf("%d", volume)})
+	return err
+}
+
+// SetRadioChannel plays the FM radio channel.
+func (g *GatewayMiIO) SetRadioChannel(id int) error {
+	_, err := g.call(cmdSetRadioChannel, map[string]interface{}{"id": id, "type": 0})
+	return err
+}
+
+// RadioChannels returns FM radio channels stored on the gateway.
+func (g *GatewayMiIO) RadioChannels() ([]*RadioChannel, error) {
+	b, err := g.call(cmdGetRadioChannels, map[string]interface{}{"start": 0})
+	if err != nil {
+		return nil, err
+	}
+
+	r := &radioChannels{}
+	err = json.Unmarshal(b, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Channels, nil
+}
+
+// Firmware returns gateway hardware and firmware information.
+func (g *GatewayMiIO) Firmware() (*GatewayFirmware, error) {
+	b, err := g.call(cmdInfo, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &GatewayFirmware{}
+	err = json.Unmarshal(b, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// GetDeviceProp returns sub-device properties in the requested order.
+func (g *GatewayMiIO) GetDeviceProp(sid string, props ...string) ([]interface{}, error) {
+	params := []interface{}{gwMiIOSidPrefix + sid}
+	for _, p := range props {
+		params = append(params, p)
+	}
+
+	b, err := g.call(cmdGetDeviceProp, params)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, 0)
+	err = json.Unmarshal(b, &values)
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// PermitJoin allows new sub-devices to join for the duration.
+// Newly joined devices are reported as EventDeviceJoined.
+func (g *GatewayMiIO) PermitJoin(duration time.Duration) error {
+	if duration <= 0 || duration > maxJoinDuration {
+		return fmt.Errorf("invalid join duration %s", duration)
+	}
+
+	known, err := g.SubDevices()
+	if err != nil {
+		return err
+	}
+
+	_, err = g.call(cmdStartJoin, []interface{}{int(duration.Seconds())})
+	if err != nil {
+		return err
+	}
+
+	go g.watchJoin(duration, known)
+	return nil
+}
+
+// RemoveDevice removes the sub-device from the gateway.
+func (g *GatewayMiIO) RemoveDevice(sid string) error {
+	_, err := g.call(cmdRemoveDevice, []interface{}{gwMiIOSidPrefix + sid})
+	return err
+}
+
+// SubDevices returns SIDs of the paired sub-devices.
+func (g *GatewayMiIO) SubDevices() ([]string, error) {
+	b, err := g.call(cmdGetDeviceProp, []interface{}{gwMiIOSid, "device_list"})
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]interface{}, 0)
+	err = json.Unmarshal(b, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	sids := make([]string, 0)
+	for ii := 0; ii < len(list); ii += deviceListStride {
+		sid, ok := list[ii].(string)
+		if !ok {
+			continue
+		}
+
+		sids = append(sids, strings.TrimPrefix(sid, gwMiIOSidPrefix))
+	}
+
+	return sids, nil
+}
+
+// Checks device list for new sub-devices while join is permitted.
+func (g *GatewayMiIO) watchJoin(duration time.Duration, known []string) {
+	deadline := time.After(duration + joinCheckInterval)
+	ticker := time.NewTicker(joinCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.done:
+			return
+		case <-deadline:
+			return
+		case <-ticker.C:
+		}
+
+		sids, err := g.SubDevices()
+		if err != nil {
+			LOGGER.Error("Failed to get gateway device list: %s", err.Error())
+			continue
+		}
+
+		for _, sid := range sids {
+			if containsString(known, sid) {
+				continue
+			}
+
+			known = append(known, sid)
+			g.publish(g.joinedMessage(sid))
+		}
+	}
+}
+
+// Returns device joined event message.
+func (g *GatewayMiIO) joinedMessage(sid string) *DeviceUpdateMessage {
+	return &DeviceUpdateMessage{
+		ID:    g.deviceID,
+		Model: g.model,
+		Kind:  UpdateKindEvent,
+		State: &Event{
+			Type:     EventDeviceJoined,
+			Priority: EventDeviceJoined.priority(),
+			SID:      g.deviceID,
+			Time:     time.Now(),
+			Data:     sid,
+		},
+	}
+}
+
+// Performs a miIO command and decodes the first value of its result.
+func (g *GatewayMiIO) callFirst(cmd string, params interface{}, v interface{}) error {
+	b, err := g.call(cmd, params)
+	if err != nil {
+		return err
+	}
+
+	values := make([]json.RawMessage, 0)
+	err = json.Unmarshal(b, &values)
+	if err != nil {
+		return err
+	}
+
+	if 0 == len(values) {
+		return fmt.Errorf("empty %s response", cmd)
+	}
+
+	return json.Unmarshal(values[0], v)
+}
+
+// Performs a miIO command and returns its result.
+func (g *GatewayMiIO) call(cmd string, params interface{}) (json.RawMessage, error) {
+	g.callLock.Lock()
+	defer g.callLock.Unlock()
+
+	if !g.sendCommand(cmd, params, true, gwMiIORetries) {
+		return nil, ErrMiIOCommand
+	}
+
+	g.Lock()
+	b, ok := g.rawState[cmd]
+	g.Unlock()
+	if !ok {
+		return nil, ErrMiIOCommand
+	}
+
+	r := &miIOResponse{}
+	err := json.Unmarshal(b.([]byte), r)
+	if err != nil {
+		return nil, err
+	}
+
+	if nil != r.Error {
+		return nil, fmt.Errorf("miio error %d: %s", r.Error.Code, r.Error.Message)
+	}
+
+	return r.Result, nil
+}
+
+// Drains internal update notifications, responses are read by call.
+func (g *GatewayMiIO) processUpdates() {
+	for {
+		if _, ok := g.nextMessage(); !ok {
+			return
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package miio
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// Creates a gateway without connections.
+func newTestGateway() *Gateway {
+	return &Gateway{
+		XiaomiDevice: XiaomiDevice{
+			done:     make(chan struct{}),
+			messages: make(chan interface{}, 100),
+			rawState: make(map[string]interface{}),
+		},
+		UpdateBus:    NewUpdateBus(),
+		state:        &GatewayState{},
+		devices:      make(map[string]internalDevice),
+		acks:         make(map[string]chan *command),
+		tokenUpdated: make(chan struct{}),
+	}
+}
+
+func TestGatewaySidSnapshot(t *testing.T) {
+	g := newTestGateway()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ii := 0; ii < 50; ii++ {
+			g.Snapshot()
+		}
+	}()
+
+	g.handlePacket([]byte(`{"cmd":"heartbeat","model":"gateway","sid":"abc","token":"t","data":"{}"}`))
+	<-done
+
+	if sid := g.Snapshot().Gateway.ID; "abc" != sid {
+		t.Errorf("gateway SID = %s, want abc", sid)
+	}
+}
+
+func TestGatewayProtocolDetection(t *testing.T) {
+	tests := []struct {
+		name    string
+		packets []string
+		want    int32
+	}{
+		{"unknown", nil, protoUnknown},
+		{"v1 device list", []string{`{"cmd":"get_id_list_ack","sid":"abc","data":"[]"}`}, protoV1},
+		{"v2 device list", []string{`{"cmd":"discovery_rsp","sid":"abc","dev_list":[]}`}, protoV2},
+		{"v2 wins", []string{`{"cmd":"discovery_rsp","sid":"abc","dev_list":[]}`,
+			`{"cmd":"get_id_list_ack","sid":"abc","data":"[]"}`}, protoV2},
+		{"v2 after v1", []string{`{"cmd":"get_id_list_ack","sid":"abc","data":"[]"}`,
+			`{"cmd":"report","sid":"abc","params":[{"rgb":0}]}`}, protoV2},
+	}
+
+	for _, tt := range tests {
+		g := newTestGateway()
+		for _, p := range tt.packets {
+			g.handlePacket([]byte(p))
+		}
+
+		if got := g.protocol(); got != tt.want {
+			t.Errorf("%s: protocol = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGatewayStopWhileHandlingPackets(t *testing.T) {
+	g := newTestGateway()
+	err := g.start("127.0.0.1", "", gatewayPort)
+	if err != nil {
+		t.Fatalf("failed to start gateway: %s", err.Error())
+	}
+
+	go g.processMessages()
+	quit := make(chan struct{})
+	var wg sync.WaitGroup
+	for ii := 0; ii < 4; ii++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-quit:
+					return
+				default:
+				}
+
+				g.handlePacket([]byte(`{"cmd":"heartbeat","model":"gateway","sid":"abc","token":"t","data":"{}"}`))
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	g.Stop()
+	time.Sleep(10 * time.Millisecond)
+	close(quit)
+	wg.Wait()
+}
+
+func TestGatewayUnchangedRead(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  string
+		data string
+		want int
+	}{
+		{"report", cmdDeviceReport, `{"status":"open","voltage":3000}`, 1},
+		{"partial heartbeat", cmdHeartBeat, `{"voltage":3000}`, 0},
+		{"same read", cmdGetDeviceState, `{"status":"open","voltage":3000}`, 0},
+		{"changed read", cmdGetDeviceState, `{"status":"close","voltage":3000}`, 1},
+	}
+
+	g := newTestGateway()
+	for _, tt := range tests {
+		msgs := g.updateDeviceState(&command{Cmd: tt.cmd,
+			deviceDTO: deviceDTO{Sid: "abc", Model: "magnet", Data: tt.data}})
+
+		got := 0
+		for _, msg := range msgs {
+			if UpdateKindState == msg.Kind {
+				got++
+			}
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: got %d state updates, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -6,9 +6,9 @@ import (
 	"fmt"
 )
 
-const _gatewayDeviceModelName = "gatewayswitchsensor_htmagnetmotion"
+const _gatewayDeviceModelName = "gatewayswitchsensor_htmagnetmotiongenericwall_switchplugwater_leaksmokenatgascubevibrationcurtainremotelock"
 
-var _gatewayDeviceModelIndex = [...]uint8{0, 7, 13, 22, 28, 34}
+var _gatewayDeviceModelIndex = [...]uint8{0, 7, 13, 22, 28, 34, 41, 52, 56, 66, 71, 77, 81, 90, 97, 103, 107}
 
 func (i gatewayDeviceModel) String() string {
 	if i < 0 || i >= gatewayDeviceModel(len(_gatewayDeviceModelIndex)-1) {
@@ -17,14 +17,25 @@ func (i gatewayDeviceModel) String() string {
 	return _gatewayDeviceModelName[_gatewayDeviceModelIndex[i]:_gatewayDeviceModelIndex[i+1]]
 }
 
-var _gatewayDeviceModelValues = []gatewayDeviceModel{0, 1, 2, 3, 4}
+var _gatewayDeviceModelValues = []gatewayDeviceModel{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
 
 var _gatewayDeviceModelNameToValueMap = map[string]gatewayDeviceModel{
-	_gatewayDeviceModelName[0:7]:   0,
-	_gatewayDeviceModelName[7:13]:  1,
-	_gatewayDeviceModelName[13:22]: 2,
-	_gatewayDeviceModelName[22:28]: 3,
-	_gatewayDeviceModelName[28:34]: 4,
+	_gatewayDeviceModelName[0:7]:     0,
+	_gatewayDeviceModelName[7:13]:    1,
+	_gatewayDeviceModelName[13:22]:   2,
+	_gatewayDeviceModelName[22:28]:   3,
+	_gatewayDeviceModelName[28:34]:   4,
+	_gatewayDeviceModelName[34:41]:   5,
+	_gatewayDeviceModelName[41:52]:   6,
+	_gatewayDeviceModelName[52:56]:   7,
+	_gatewayDeviceModelName[56:66]:   8,
+	_gatewayDeviceModelName[66:71]:   9,
+	_gatewayDeviceModelName[71:77]:   10,
+	_gatewayDeviceModelName[77:81]:   11,
+	_gatewayDeviceModelName[81:90]:   12,
+	_gatewayDeviceModelName[90:97]:   13,
+	_gatewayDeviceModelName[97:103]:  14,
+	_gatewayDeviceModelName[103:107]: 15,
 }
 
 // gatewayDeviceModelString retrieves an enum value from the enum constants string name.
@@ -0,0 +1,67 @@
+package miio
+
+import "time"
+
+// GenericSubDeviceState describes a state of the sub-device without dedicated support.
+type GenericSubDeviceState struct {
+	BatteryState
+
+	Model    string
+	Data     map[string]interface{}
+	LastSeen time.Time
+}
+
+// GenericSubDevice defines a gateway sub-device of unknown model.
+// Raw reported data is passed through as is.
+type GenericSubDevice struct {
+	XiaomiDevice
+
+	ID      string
+	Gateway *Gateway
+	state   *GenericSubDeviceState
+}
+
+// Stops is not uses for gateway devices.
+func (d *GenericSubDevice) Stop() {
+}
+
+// GetUpdateMessage returns device's state update message.
+func (d *GenericSubDevice) GetUpdateMessage() *DeviceUpdateMessage {
+	d.Gateway.Lock()
+	defer d.Gateway.Unlock()
+
+	return d.updateMessage()
+}
+
+// Returns device's state update message. Gateway lock must be held.
+func (d *GenericSubDevice) updateMessage() *DeviceUpdateMessage {
+	return &DeviceUpdateMessage{
+		ID:      d.ID,
+		Model:   d.model,
+		Kind:    UpdateKindState,
+		Version: d.version,
+		State:   d.stateCopy(),
+	}
+}
+
+// Returns a copy of the device state.
+func (d *GenericSubDevice) stateCopy() interface{} {
+	st := *d.state
+	st.Data = make(map[string]interface{}, len(d.state.Data))
+	for k, v := range d.state.Data {
+		st.Data[k] = v
+	}
+
+	return &st
+}
+
+// UpdateState performs a device update.
+// Reports are usually partial, so data is merged with previously reported values.
+func (d *GenericSubDevice) UpdateState() {
+	d.updateBattery(&d.state.BatteryState)
+	d.state.Model = d.model
+	d.state.LastSeen = time.Now()
+	for k, v := range d.rawState {
+		d.state.Data[k] = v
+	}
+}
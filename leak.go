@@ -0,0 +1,69 @@
+package miio
+
+const (
+	leakStatusLeak   = "leak"
+	leakStatusNoLeak = "no_leak"
+)
+
+// WaterLeakState describes a state of the water leak sensor.
+type WaterLeakState struct {
+	BatteryState
+
+	Leak bool
+}
+
+// WaterLeak defines an Aqara water leak sensor.
+type WaterLeak struct {
+	XiaomiDevice
+
+	ID      string
+	Gateway *Gateway
+	state   *WaterLeakState
+}
+
+// Stops is not uses for gateway devices.
+func (w *WaterLeak) Stop() {
+}
+
+// GetUpdateMessage returns device's state update message.
+func (w *WaterLeak) GetUpdateMessage() *DeviceUpdateMessage {
+	w.Gateway.Lock()
+	defer w.Gateway.Unlock()
+
+	return w.updateMessage()
+}
+
+// Returns device's state update message. Gateway lock must be held.
+func (w *WaterLeak) updateMessage() *DeviceUpdateMessage {
+	return &DeviceUpdateMessage{
+		ID:      w.ID,
+		Model:   w.model,
+		Kind:    UpdateKindState,
+		Version: w.version,
+		State:   w.stateCopy(),
+	}
+}
+
+// Returns a copy of the device state.
+func (w *WaterLeak) stateCopy() interface{} {
+	st := *w.state
+	return &st
+}
+
+// UpdateState performs a device update.
+func (w *WaterLeak) UpdateState() {
+	w.updateBattery(&w.state.BatteryState)
+
+	switch w.getFieldValue(fieldStatus) {
+	case leakStatusLeak:
+		if !w.state.Leak {
+			w.addEvent(EventLeak)
+		}
+		w.state.Leak = true
+	case leakStatusNoLeak:
+		if w.state.Leak {
+			w.addEvent(EventLeakCleared)
+		}
+		w.state.Leak = false
+	}
+}
@@ -0,0 +1,102 @@
+package miio
+
+import "time"
+
+// UnlockMethod defines the way door lock was unlocked.
+type UnlockMethod int
+
+const (
+	// UnlockFingerprint describes fingerprint unlock.
+	UnlockFingerprint UnlockMethod = iota
+	// UnlockPassword describes password unlock.
+	UnlockPassword
+	// UnlockNFC describes NFC card unlock.
+	UnlockNFC
+	// UnlockKey describes mechanical key unlock.
+	UnlockKey
+)
+
+var (
+	// Fields reporting successful unlock.
+	lockUnlockFields = map[fldName]UnlockMethod{
+		fieldFingVerified: UnlockFingerprint,
+		fieldPswVerified:  UnlockPassword,
+		fieldCardVerified: UnlockNFC,
+		fieldKeyVerified:  UnlockKey,
+	}
+)
+
+// LockUnlock describes a single unlock.
+type LockUnlock struct {
+	Method UnlockMethod
+	UserID int
+	Time   time.Time
+}
+
+// LockState describes a state of the door lock.
+type LockState struct {
+	LastUnlock    LockUnlock
+	WrongAttempts int
+}
+
+// Lock defines an Aqara door lock.
+type Lock struct {
+	XiaomiDevice
+
+	ID      string
+	Gateway *Gateway
+	state   *LockState
+}
+
+// Stops is not uses for gateway devices.
+func (l *Lock) Stop() {
+}
+
+// GetUpdateMessage returns device's state update message.
+func (l *Lock) GetUpdateMessage() *DeviceUpdateMessage {
+	l.Gateway.Lock()
+	defer l.Gateway.Unlock()
+
+	return l.updateMessage()
+}
+
+// Returns device's state update message. Gateway lock must be held.
+func (l *Lock) updateMessage() *DeviceUpdateMessage {
+	return &DeviceUpdateMessage{
+		ID:      l.ID,
+		Model:   l.model,
+		Kind:    UpdateKindState,
+		Version: l.version,
+		State:   l.stateCopy(),
+	}
+}
+
+// Returns a copy of the device state.
+func (l *Lock) stateCopy() interface{} {
+	st := *l.state
+	return &st
+}
+
+// UpdateState performs a device update.
+func (l *Lock) UpdateState() {
+	for field, method := range lockUnlockFields {
+		if "" == l.getFieldValue(field) {
+			continue
+		}
+
+		l.state.LastUnlock = LockUnlock{
+			Method: method,
+			UserID: int(l.GetFieldValueInt32(field, 0)),
+			Time:   time.Now(),
+		}
+		l.state.WrongAttempts = 0
+
+		unlock := l.state.LastUnlock
+		l.addEventData(EventUnlock, &unlock)
+	}
+
+	if "" != l.getFieldValue(fieldVerifiedWrong) {
+		l.state.WrongAttempts = int(l.GetFieldValueInt32(fieldVerifiedWrong, int32(l.state.WrongAttempts)))
+		l.addEventData(EventWrongAttempt, l.state.WrongAttempts)
+	}
+}
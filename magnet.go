@@ -2,8 +2,9 @@ package miio
 
 // MagnetState describes a state of the magnet.
 type MagnetState struct {
-	Opened  bool
-	Battery float32
+	BatteryState
+
+	Opened bool
 }
 
 // Magnet defines a Xiaomi magnet.
@@ -12,7 +13,7 @@ type Magnet struct {
 
 	ID      string
 	Gateway *Gateway
-	State   *MagnetState
+	state   *MagnetState
 }
 
 // Stops is not uses for gateway devices.
@@ -21,14 +22,31 @@ func (m *Magnet) Stop() {
 
 // GetUpdateMessage returns device's state update message.
 func (m *Magnet) GetUpdateMessage() *DeviceUpdateMessage {
+	m.Gateway.Lock()
+	defer m.Gateway.Unlock()
+
+	return m.updateMessage()
+}
+
+// Returns device's state update message. Gateway lock must be held.
+func (m *Magnet) updateMessage() *DeviceUpdateMessage {
 	return &DeviceUpdateMessage{
-		ID:    m.ID,
-		State: m.State,
+		ID:      m.ID,
+		Model:   m.model,
+		Kind:    UpdateKindState,
+		Version: m.version,
+		State:   m.stateCopy(),
 	}
 }
 
+// Returns a copy of the device state.
+func (m *Magnet) stateCopy() interface{} {
+	st := *m.state
+	return &st
+}
+
 // UpdateState performs a device update.
 func (m *Magnet) UpdateState() {
-	m.State.Battery = m.GetBatteryLevel(m.State.Battery)
-	m.State.Opened = m.GetFieldValueBool(fieldStatus, m.State.Opened)
+	m.updateBattery(&m.state.BatteryState)
+	m.state.Opened = m.GetFieldValueBool(fieldStatus, m.state.Opened)
 }
@@ -0,0 +1,189 @@
+package miio
+
+import (
+	"encoding/json"
+	"net"
+	"sort"
+	"sync"
+)
+
+// GatewayManager handles multiple gateways using a single multi-cast listener.
+// Updates from all gateways are merged into manager's update bus.
+type GatewayManager struct {
+	sync.Mutex
+	*UpdateBus
+
+	multiCast *net.UDPConn
+	opts      *GatewayOptions
+	gateways  map[string]*Gateway
+	subs      map[*Gateway]*Subscription
+}
+
+// NewGatewayManager creates a new gateway manager.
+func NewGatewayManager() (*GatewayManager, error) {
+	return NewGatewayManagerWithOptions(nil)
+}
+
+// NewGatewayManagerWithOptions creates a new gateway manager with custom connection options.
+// Options are applied to the shared listener and all added gateways.
+func NewGatewayManagerWithOptions(opts *GatewayOptions) (*GatewayManager, error) {
+	l, err := opts.listenMultiCast()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &GatewayManager{
+		UpdateBus: NewUpdateBus(),
+		multiCast: l,
+		opts:      opts,
+		gateways:  make(map[string]*Gateway),
+		subs:      make(map[*Gateway]*Subscription),
+	}
+
+	go m.listenMultiCast()
+	return m, nil
+}
+
+// AddGateway connects to a new gateway.
+func (m *GatewayManager) AddGateway(deviceIP, aesKey string) (*Gateway, error) {
+	g, err := newGateway(deviceIP, aesKey, m.opts, false)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.gateways[g.ip.String()] = g
+	m.subs[g] = g.SubscribeFunc(nil, 0, OverflowBlock, m.publish)
+	return g, nil
+}
+
+// RemoveGateway stops the gateway and removes it from the manager.
+func (m *GatewayManager) RemoveGateway(g *Gateway) {
+	m.Lock()
+	delete(m.gateways, g.ip.String())
+	sub, ok := m.subs[g]
+	delete(m.subs, g)
+	m.Unlock()
+
+	if ok {
+		g.Unsubscribe(sub)
+	}
+
+	g.Stop()
+}
+
+// Gateways returns all managed gateways.
+func (m *GatewayManager) Gateways() []*Gateway {
+	m.Lock()
+	defer m.Unlock()
+
+	res := make([]*Gateway, 0, len(m.gateways))
+	for _, v := range m.gateways {
+		res = append(res, v)
+	}
+
+	return res
+}
+
+// Devices returns snapshots of sub-devices of all gateways sorted by ID.
+func (m *GatewayManager) Devices() []*DeviceSnapshot {
+	res := make([]*DeviceSnapshot, 0)
+	for _, g := range m.Gateways() {
+		res = append(res, g.Devices()...)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+
+	return res
+}
+
+// Device returns a sub-device snapshot from any gateway or nil if it's unknown.
+func (m *GatewayManager) Device(sid string) *DeviceSnapshot {
+	for _, g := range m.Gateways() {
+		d := g.Device(sid)
+		if nil != d {
+			return d
+		}
+	}
+
+	return nil
+}
+
+// SubDevice returns a sub-device from any gateway or nil if it's unknown.
+func (m *GatewayManager) SubDevice(sid string) IDevice {
+	for _, g := range m.Gateways() {
+		d := g.SubDevice(sid)
+		if nil != d {
+			return d
+		}
+	}
+
+	return nil
+}
+
+// Stop stops all gateways and the listener.
+func (m *GatewayManager) Stop() {
+	m.multiCast.Close()
+	for _, g := range m.Gateways() {
+		m.RemoveGateway(g)
+	}
+
+	m.close()
+}
+
+// Listens for a multi-cast messages and routes them to gateways.
+func (m *GatewayManager) listenMultiCast() {
+	buf := make([]byte, 2048)
+	for {
+		size, addr, err := m.multiCast.ReadFromUDP(buf)
+		if err != nil {
+			LOGGER.Error("Error reading from MultiCast: %s", err.Error())
+			return
+		}
+
+		if 0 == size {
+			continue
+		}
+
+		LOGGER.Debug("Received device message: %s", string(buf[0:size]))
+		msg := make([]byte, size)
+		copy(msg, buf[0:size])
+
+		g := m.route(addr.IP, msg)
+		if nil == g {
+			LOGGER.Debug("No gateway found for message from %s", addr.IP.String())
+			continue
+		}
+
+		g.handlePacket(msg)
+	}
+}
+
+// Finds the gateway by source IP, falls back to SID lookup.
+func (m *GatewayManager) route(ip net.IP, msg []byte) *Gateway {
+	m.Lock()
+	g, ok := m.gateways[ip.String()]
+	m.Unlock()
+
+	if ok {
+		return g
+	}
+
+	cmd := &command{}
+	err := json.Unmarshal(msg, cmd)
+	if err != nil || "" == cmd.Sid {
+		return nil
+	}
+
+	for _, g := range m.Gateways() {
+		if g.owns(cmd.Sid) {
+			return g
+		}
+	}
+
+	return nil
+}
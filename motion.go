@@ -4,9 +4,12 @@ import "time"
 
 // MotionState describes a state of the motion sensor.
 type MotionState struct {
-	Battery    float32
+	BatteryState
+
 	HasMotion  bool
 	LastMotion time.Time
+	// Illuminance, lux. Reported by sensor_motion.aq2 only.
+	Illuminance uint32
 }
 
 // Motion defines a Xiaomi motion sensor.
@@ -15,7 +18,7 @@ type Motion struct {
 
 	ID      string
 	Gateway *Gateway
-	State   *MotionState
+	state   *MotionState
 }
 
 // Stops is not uses for gateway devices.
@@ -24,24 +27,56 @@ func (m *Motion) Stop() {
 
 // GetUpdateMessage returns device's state update message.
 func (m *Motion) GetUpdateMessage() *DeviceUpdateMessage {
+	m.Gateway.Lock()
+	defer m.Gateway.Unlock()
+
+	return m.updateMessage()
+}
+
+// Returns device's state update message. Gateway lock must be held.
+func (m *Motion) updateMessage() *DeviceUpdateMessage {
 	return &DeviceUpdateMessage{
-		ID:    m.ID,
-		State: m.State,
+		ID:      m.ID,
+		Model:   m.model,
+		Kind:    UpdateKindState,
+		Version: m.version,
+		State:   m.stateCopy(),
 	}
 }
 
+// Returns a copy of the device state.
+func (m *Motion) stateCopy() interface{} {
+	st := *m.state
+	return &st
+}
+
 // UpdateState performs a device update.
 func (m *Motion) UpdateState() {
-	m.State.Battery = m.GetBatteryLevel(m.State.Battery)
-	m.State.HasMotion = m.GetFieldValueBool(fieldStatus, m.State.HasMotion)
+	m.updateBattery(&m.state.BatteryState)
+	m.state.HasMotion = m.GetFieldValueBool(fieldStatus, m.state.HasMotion)
 	noMotion := m.GetFieldValueInt32(fieldNoMotion, 0)
 	if noMotion > 0 {
-		m.State.HasMotion = false
+		m.state.HasMotion = false
 		lastMotion := time.Now().Add(-1 * time.Duration(noMotion) * time.Second)
-		m.State.LastMotion = lastMotion
+		m.state.LastMotion = lastMotion
 	}
 
-	if m.State.HasMotion {
-		m.State.LastMotion = time.Now()
+	if m.state.HasMotion {
+		m.state.LastMotion = time.Now()
 	}
+
+	if m.GetFieldValueBool(fieldStatus, false) {
+		m.addEvent(EventMotion)
+	}
+
+	if m.hasIlluminance() {
+		// Motion reports carry lux, periodic reports carry illumination.
+		m.state.Illuminance = m.GetFieldValueUint32(fieldIllumination, m.state.Illuminance)
+		m.state.Illuminance = m.GetFieldValueUint32(fieldLux, m.state.Illuminance)
+	}
+}
+
+// Checks whether sensor reports illuminance.
+func (m *Motion) hasIlluminance() bool {
+	return "sensor_motion.aq2" == m.model
 }
@@ -0,0 +1,235 @@
+package miio
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoMulticastInterface is returned if host has no interface capable of multi-cast.
+	ErrNoMulticastInterface = errors.New("no multi-cast capable network interface found")
+)
+
+// GatewayOptions defines gateway connection options.
+// Zero values mean defaults.
+type GatewayOptions struct {
+	// Interface is a name or an IP address of the multi-cast interface.
+	Interface string
+	// LocalAddr is a local address of the unicast socket, either "ip" or "ip:port".
+	LocalAddr string
+	// Port is the gateway unicast port.
+	Port int
+	// MulticastGroup is the gateway multi-cast group.
+	MulticastGroup string
+	// MulticastPort is the gateway multi-cast port.
+	MulticastPort int
+	// DisableMulticast disables multi-cast listener, usually combined with PollInterval.
+	DisableMulticast bool
+	// PollInterval enables periodic unicast polling of all devices.
+	// With DisableMulticast gateway is considered offline after missing 3 polls.
+	PollInterval time.Duration
+}
+
+// Returns gateway port.
+func (o *GatewayOptions) port() int {
+	if nil == o || 0 == o.Port {
+		return gatewayPort
+	}
+
+	return o.Port
+}
+
+// Checks whether multi-cast listener should be started.
+func (o *GatewayOptions) multiCastEnabled() bool {
+	return nil == o || !o.DisableMulticast
+}
+
+// Returns polling interval, zero means no polling.
+func (o *GatewayOptions) pollInterval() time.Duration {
+	if nil == o {
+		return 0
+	}
+
+	return o.PollInterval
+}
+
+// Returns gateway availability timeout.
+// Without multi-cast gateway sends no heartbeats and is seen only on polls.
+func (o *GatewayOptions) gatewayTimeout() time.Duration {
+	t := availabilityTimeout(devGateway)
+	if o.multiCastEnabled() {
+		return t
+	}
+
+	if 0 == o.pollInterval() {
+		// Nothing refreshes availability, so it's not tracked.
+		return 0
+	}
+
+	if p := pollMissesOffline * o.pollInterval(); p > t {
+		return p
+	}
+
+	return t
+}
+
+// Returns multi-cast group address.
+func (o *GatewayOptions) multiCastAddr() (*net.UDPAddr, error) {
+	addr := &net.UDPAddr{
+		IP:   multicastIP,
+		Port: gatewayPort,
+	}
+
+	if nil == o {
+		return addr, nil
+	}
+
+	if "" != o.MulticastGroup {
+		addr.IP = net.ParseIP(o.MulticastGroup)
+		if nil == addr.IP || !addr.IP.IsMulticast() {
+			return nil, fmt.Errorf("invalid multi-cast group %s", o.MulticastGroup)
+		}
+	}
+
+	if 0 != o.MulticastPort {
+		addr.Port = o.MulticastPort
+	}
+
+	return addr, nil
+}
+
+// Returns local address of the unicast socket.
+func (o *GatewayOptions) localAddr() (*net.UDPAddr, error) {
+	if nil == o || "" == o.LocalAddr {
+		return nil, nil
+	}
+
+	host, port := o.LocalAddr, 0
+	if strings.Contains(o.LocalAddr, ":") {
+		h, p, err := net.SplitHostPort(o.LocalAddr)
+		if err != nil {
+			return nil, err
+		}
+
+		port, err = strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid local port %s", p)
+		}
+
+		host = h
+	}
+
+	ip := net.ParseIP(host)
+	if nil == ip {
+		return nil, fmt.Errorf("invalid local address %s", o.LocalAddr)
+	}
+
+	return &net.UDPAddr{
+		IP:   ip,
+		Port: port,
+	}, nil
+}
+
+// Returns multi-cast interface. Nil means system default.
+func (o *GatewayOptions) multiCastInterface() (*net.Interface, error) {
+	if nil == o || "" == o.Interface {
+		return nil, nil
+	}
+
+	ip := net.ParseIP(o.Interface)
+	if nil == ip {
+		ifi, err := net.InterfaceByName(o.Interface)
+		if err != nil {
+			return nil, fmt.Errorf("interface %s was not found: %s", o.Interface, err.Error())
+		}
+
+		return ifi, nil
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	for ii := range ifaces {
+		addrs, err := ifaces[ii].Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, a := range addrs {
+			n, ok := a.(*net.IPNet)
+			if ok && n.IP.Equal(ip) {
+				return &ifaces[ii], nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no interface with address %s was found", o.Interface)
+}
+
+// Returns multi-cast address used for gateway discovery.
+func (o *GatewayOptions) whoisAddr() (*net.UDPAddr, error) {
+	addr, err := o.multiCastAddr()
+	if err != nil {
+		return nil, err
+	}
+
+	addr.Port = whoisPort
+	return addr, nil
+}
+
+// Joins the gateway multi-cast group.
+func (o *GatewayOptions) listenMultiCast() (*net.UDPConn, error) {
+	addr, err := o.multiCastAddr()
+	if err != nil {
+		return nil, err
+	}
+
+	return o.joinMultiCast(addr)
+}
+
+// Joins the multi-cast group on the configured interface.
+func (o *GatewayOptions) joinMultiCast(addr *net.UDPAddr) (*net.UDPConn, error) {
+	ifi, err := o.multiCastInterface()
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := net.ListenMulticastUDP("udp4", ifi, addr)
+	if err != nil {
+		return nil, multiCastError(addr, ifi, err)
+	}
+
+	return l, nil
+}
+
+// Builds a diagnostic error for a failed multi-cast join.
+func multiCastError(addr *net.UDPAddr, ifi *net.Interface, err error) error {
+	if nil != ifi {
+		return fmt.Errorf("failed to join multi-cast group %s on %s: %s", addr.String(), ifi.Name, err.Error())
+	}
+
+	ifaces, ifErr := net.Interfaces()
+	if ifErr != nil {
+		return fmt.Errorf("failed to join multi-cast group %s: %s", addr.String(), err.Error())
+	}
+
+	names := make([]string, 0)
+	for _, v := range ifaces {
+		if v.Flags&net.FlagUp != 0 && v.Flags&net.FlagMulticast != 0 && v.Flags&net.FlagLoopback == 0 {
+			names = append(names, v.Name)
+		}
+	}
+
+	if 0 == len(names) {
+		return ErrNoMulticastInterface
+	}
+
+	return fmt.Errorf("failed to join multi-cast group %s: %s, set GatewayOptions.Interface to one of: %s",
+		addr.String(), err.Error(), strings.Join(names, ", "))
+}
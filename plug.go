@@ -0,0 +1,76 @@
+package miio
+
+// PlugState describes a state of the smart plug.
+type PlugState struct {
+	On    bool
+	InUse bool
+	// Load power, W.
+	LoadPower float64
+	// Consumed energy, Wh.
+	PowerConsumed float64
+}
+
+// Plug defines a Xiaomi smart plug.
+type Plug struct {
+	XiaomiDevice
+
+	ID      string
+	Gateway *Gateway
+	state   *PlugState
+}
+
+// Stops is not uses for gateway devices.
+func (p *Plug) Stop() {
+}
+
+// GetUpdateMessage returns device's state update message.
+func (p *Plug) GetUpdateMessage() *DeviceUpdateMessage {
+	p.Gateway.Lock()
+	defer p.Gateway.Unlock()
+
+	return p.updateMessage()
+}
+
+// Returns device's state update message. Gateway lock must be held.
+func (p *Plug) updateMessage() *DeviceUpdateMessage {
+	return &DeviceUpdateMessage{
+		ID:      p.ID,
+		Model:   p.model,
+		Kind:    UpdateKindState,
+		Version: p.version,
+		State:   p.stateCopy(),
+	}
+}
+
+// Returns a copy of the device state.
+func (p *Plug) stateCopy() interface{} {
+	st := *p.state
+	return &st
+}
+
+// UpdateState performs a device update.
+func (p *Plug) UpdateState() {
+	p.state.On = p.GetFieldValueBool(fieldStatus, p.state.On)
+	p.state.InUse = p.GetFieldValueBool(fieldInuse, p.state.InUse)
+	p.state.LoadPower = p.GetFieldValueFloat64(fieldLoadPower, p.state.LoadPower)
+	p.state.PowerConsumed = p.GetFieldValueFloat64(fieldPowerConsumed, p.state.PowerConsumed)
+}
+
+// On turns the plug on.
+func (p *Plug) On() error {
+	return p.setStatus(valueOn)
+}
+
+// Off turns the plug off.
+func (p *Plug) Off() error {
+	return p.setStatus(valueOff)
+}
+
+// Sends the plug status to the gateway.
+func (p *Plug) setStatus(val string) error {
+	data := map[string]interface{}{
+		fieldStatus.String(): val,
+	}
+
+	return p.Gateway.write(data, p.ID, p.model)
+}
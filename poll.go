@@ -0,0 +1,32 @@
+package miio
+
+import (
+	"reflect"
+	"time"
+)
+
+// Periodically requests the device list and device states over unicast.
+// Used when multi-cast reports are not delivered.
+func (g *Gateway) poll(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.done:
+			return
+		case <-ticker.C:
+		}
+
+		err := g.getDevices()
+		if err != nil {
+			LOGGER.Error("Failed to poll gateway: %s", err.Error())
+		}
+	}
+}
+
+// Checks whether device state wasn't changed by an update.
+// Reports are often partial, so raw data can't be compared.
+func isUnchanged(before, after interface{}) bool {
+	return reflect.DeepEqual(before, after)
+}
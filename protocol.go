@@ -0,0 +1,134 @@
+package miio
+
+import (
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// Gateway protocol versions.
+	protoUnknown int32 = 0
+	protoV1      int32 = 1
+	protoV2      int32 = 2
+
+	cmdRsp           = "_rsp"
+	cmdGetDevicesV2  = "discovery"
+	cmdGetDevicesRsp = "discovery_rsp"
+)
+
+var (
+	// Protocol v2 keys which differ from protocol v1, per device model.
+	v2Keys = map[gatewayDeviceModel]map[string]string{
+		devMagnet:    {"window_status": "status"},
+		devMotion:    {"motion_status": "status"},
+		devSwitch:    {"button_0": "status"},
+		devPlug:      {"channel_0": "status"},
+		devWaterLeak: {"wleak_status": "status"},
+		devCube:      {"cube_status": "status"},
+	}
+
+	// Protocol v2 keys which differ from protocol v1 for all devices.
+	v2CommonKeys = map[string]string{
+		"battery_voltage": "voltage",
+	}
+)
+
+// Returns detected gateway protocol version.
+func (g *Gateway) protocol() int32 {
+	return atomic.LoadInt32(&g.protoVersion)
+}
+
+// Checks whether message uses protocol v2 format.
+func (c *command) isV2() bool {
+	return nil != c.Params || nil != c.DevList || strings.HasSuffix(c.Cmd, cmdRsp)
+}
+
+// Converts protocol v2 message into v1 representation.
+func (c *command) toV1() {
+	switch {
+	case cmdGetDevicesRsp == c.Cmd:
+		c.Cmd = cmdGetDevices
+		sids := make([]string, 0, len(c.DevList))
+		for _, v := range c.DevList {
+			sids = append(sids, v.Sid)
+		}
+
+		b, _ := json.Marshal(sids)
+		c.Data = string(b)
+	case cmdSetDeviceState+cmdRsp == c.Cmd:
+		// Write responses are acknowledgements and may carry an error.
+		c.Cmd = cmdSetDeviceState + cmdAck
+	case strings.HasSuffix(c.Cmd, cmdRsp):
+		c.Cmd = strings.TrimSuffix(c.Cmd, cmdRsp)
+	}
+
+	if nil != c.Params {
+		keys := v2Keys[parseGatewayDeviceModel(c.Model)]
+		data := make(map[string]interface{})
+		for _, p := range c.Params {
+			for k, v := range p {
+				data[v1Key(keys, k)] = v
+			}
+		}
+
+		b, _ := json.Marshal(data)
+		c.Data = string(b)
+	}
+
+	c.Params = nil
+	c.DevList = nil
+}
+
+// Fills command payload according to the protocol version.
+func (c *command) setPayload(version int32, data map[string]interface{}, key string) {
+	if protoV2 != version {
+		data["key"] = key
+		b, _ := json.Marshal(data)
+		c.Data = string(b)
+		return
+	}
+
+	if cmdGetDevices == c.Cmd {
+		c.Cmd = cmdGetDevicesV2
+		return
+	}
+
+	if cmdSetDeviceState == c.Cmd {
+		c.Key = key
+	}
+
+	if len(data) > 0 {
+		keys := v2Keys[parseGatewayDeviceModel(c.Model)]
+		params := make(map[string]interface{}, len(data))
+		for k, v := range data {
+			params[v2Key(keys, k)] = v
+		}
+
+		c.Params = []map[string]interface{}{params}
+	}
+}
+
+// Returns protocol v2 name of the protocol v1 key.
+func v2Key(keys map[string]string, key string) string {
+	for k, v := range keys {
+		if v == key {
+			return k
+		}
+	}
+
+	return key
+}
+
+// Returns protocol v1 name of the protocol v2 key.
+func v1Key(keys map[string]string, key string) string {
+	if k, ok := keys[key]; ok {
+		return k
+	}
+
+	if k, ok := v2CommonKeys[key]; ok {
+		return k
+	}
+
+	return key
+}
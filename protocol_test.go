@@ -0,0 +1,228 @@
+package miio
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCommandIsV2(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  *command
+		want bool
+	}{
+		{"v1 report", &command{Cmd: "report", deviceDTO: deviceDTO{Data: "{}"}}, false},
+		{"v1 ack", &command{Cmd: "write_ack"}, false},
+		{"v2 params", &command{Cmd: "report", Params: []map[string]interface{}{{}}}, true},
+		{"v2 device list", &command{Cmd: "discovery_rsp", DevList: []*devListItem{}}, true},
+		{"v2 response", &command{Cmd: "write_rsp"}, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.cmd.isV2(); got != tt.want {
+			t.Errorf("%s: isV2() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCommandToV1(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     *command
+		wantCmd string
+		want    interface{}
+	}{
+		{
+			name: "device list",
+			cmd: &command{Cmd: "discovery_rsp", DevList: []*devListItem{
+				{Sid: "a", Model: "magnet"}, {Sid: "b", Model: "motion"}}},
+			wantCmd: cmdGetDevices,
+			want:    []interface{}{"a", "b"},
+		},
+		{
+			name: "read response",
+			cmd: &command{Cmd: "read_rsp", deviceDTO: deviceDTO{Model: "sensor_ht"},
+				Params: []map[string]interface{}{{"temperature": 2100.0}, {"humidity": 5000.0}}},
+			wantCmd: cmdGetDeviceState,
+			want:    map[string]interface{}{"temperature": 2100.0, "humidity": 5000.0},
+		},
+		{
+			name:    "write response",
+			cmd:     &command{Cmd: "write_rsp", Params: []map[string]interface{}{{"error": "Invalid key"}}},
+			wantCmd: cmdSetDeviceState + cmdAck,
+			want:    map[string]interface{}{"error": "Invalid key"},
+		},
+		{
+			name: "magnet status",
+			cmd: &command{Cmd: "report", deviceDTO: deviceDTO{Model: "magnet"},
+				Params: []map[string]interface{}{{"window_status": "open"}}},
+			wantCmd: cmdDeviceReport,
+			want:    map[string]interface{}{"status": "open"},
+		},
+		{
+			name: "motion status",
+			cmd: &command{Cmd: "report", deviceDTO: deviceDTO{Model: "motion"},
+				Params: []map[string]interface{}{{"motion_status": "motion"}}},
+			wantCmd: cmdDeviceReport,
+			want:    map[string]interface{}{"status": "motion"},
+		},
+		{
+			name: "switch click",
+			cmd: &command{Cmd: "report", deviceDTO: deviceDTO{Model: "switch"},
+				Params: []map[string]interface{}{{"button_0": "click"}, {"battery_voltage": 3000.0}}},
+			wantCmd: cmdDeviceReport,
+			want:    map[string]interface{}{"status": "click", "voltage": 3000.0},
+		},
+		{
+			name: "wall switch keeps channels",
+			cmd: &command{Cmd: "report", deviceDTO: deviceDTO{Model: "ctrl_neutral2"},
+				Params: []map[string]interface{}{{"channel_0": "on"}}},
+			wantCmd: cmdDeviceReport,
+			want:    map[string]interface{}{"channel_0": "on"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.cmd.toV1()
+		if tt.cmd.Cmd != tt.wantCmd {
+			t.Errorf("%s: cmd = %s, want %s", tt.name, tt.cmd.Cmd, tt.wantCmd)
+		}
+
+		if nil != tt.cmd.Params || nil != tt.cmd.DevList {
+			t.Errorf("%s: v2 fields are not cleared", tt.name)
+		}
+
+		var got interface{}
+		err := json.Unmarshal([]byte(tt.cmd.Data), &got)
+		if err != nil {
+			t.Fatalf("%s: failed to un-marshal data: %s", tt.name, err.Error())
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: data = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCommandSetPayload(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    int32
+		cmd        string
+		model      string
+		data       map[string]interface{}
+		wantCmd    string
+		wantKey    string
+		wantData   string
+		wantParams []map[string]interface{}
+	}{
+		{
+			name:     "v1 write",
+			version:  protoV1,
+			cmd:      cmdSetDeviceState,
+			data:     map[string]interface{}{"rgb": 1},
+			wantCmd:  cmdSetDeviceState,
+			wantData: `{"key":"k","rgb":1}`,
+		},
+		{
+			name:       "v2 write",
+			version:    protoV2,
+			cmd:        cmdSetDeviceState,
+			data:       map[string]interface{}{"rgb": 1},
+			wantCmd:    cmdSetDeviceState,
+			wantKey:    "k",
+			wantParams: []map[string]interface{}{{"rgb": 1}},
+		},
+		{
+			name:     "v1 plug write",
+			version:  protoV1,
+			cmd:      cmdSetDeviceState,
+			model:    "plug",
+			data:     map[string]interface{}{"status": "on"},
+			wantCmd:  cmdSetDeviceState,
+			wantData: `{"key":"k","status":"on"}`,
+		},
+		{
+			name:       "v2 plug write",
+			version:    protoV2,
+			cmd:        cmdSetDeviceState,
+			model:      "plug",
+			data:       map[string]interface{}{"status": "on"},
+			wantCmd:    cmdSetDeviceState,
+			wantKey:    "k",
+			wantParams: []map[string]interface{}{{"channel_0": "on"}},
+		},
+		{
+			name:       "v2 wall switch write",
+			version:    protoV2,
+			cmd:        cmdSetDeviceState,
+			model:      "ctrl_neutral2",
+			data:       map[string]interface{}{"channel_1": "off"},
+			wantCmd:    cmdSetDeviceState,
+			wantKey:    "k",
+			wantParams: []map[string]interface{}{{"channel_1": "off"}},
+		},
+		{
+			name:    "v2 read",
+			version: protoV2,
+			cmd:     cmdGetDeviceState,
+			data:    map[string]interface{}{},
+			wantCmd: cmdGetDeviceState,
+		},
+		{
+			name:    "v2 device list",
+			version: protoV2,
+			cmd:     cmdGetDevices,
+			data:    map[string]interface{}{},
+			wantCmd: cmdGetDevicesV2,
+		},
+	}
+
+	for _, tt := range tests {
+		c := &command{Cmd: tt.cmd, deviceDTO: deviceDTO{Model: tt.model}}
+		c.setPayload(tt.version, tt.data, "k")
+		if c.Cmd != tt.wantCmd || c.Key != tt.wantKey || c.Data != tt.wantData {
+			t.Errorf("%s: got cmd %s, key %s, data %s", tt.name, c.Cmd, c.Key, c.Data)
+		}
+
+		if !reflect.DeepEqual(c.Params, tt.wantParams) {
+			t.Errorf("%s: params = %v, want %v", tt.name, c.Params, tt.wantParams)
+		}
+	}
+}
+
+func TestWriteAck(t *testing.T) {
+	tests := []struct {
+		name    string
+		packet  string
+		wantErr error
+	}{
+		{"v1 ack", `{"cmd":"write_ack","sid":"abc","data":"{\"status\":\"on\"}"}`, nil},
+		{"v1 error", `{"cmd":"write_ack","sid":"abc","data":"{\"error\":\"Invalid key\"}"}`, ErrInvalidKey},
+		{"v2 ack", `{"cmd":"write_rsp","sid":"abc","params":[{"status":"on"}]}`, nil},
+		{"v2 error", `{"cmd":"write_rsp","sid":"abc","params":[{"error":"Invalid device"}]}`, ErrInvalidDevice},
+	}
+
+	for _, tt := range tests {
+		ack := make(chan *command, 1)
+		g := &Gateway{
+			XiaomiDevice: XiaomiDevice{
+				done:     make(chan struct{}),
+				messages: make(chan interface{}, 1),
+			},
+			acks: map[string]chan *command{"abc": ack},
+		}
+
+		g.handlePacket([]byte(tt.packet))
+		select {
+		case cmd := <-ack:
+			if err := parseAckError(cmd); err != tt.wantErr {
+				t.Errorf("%s: error = %v, want %v", tt.name, err, tt.wantErr)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("%s: acknowledgement is not delivered", tt.name)
+		}
+	}
+}
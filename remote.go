@@ -0,0 +1,94 @@
+package miio
+
+import "time"
+
+var (
+	// Remote single button clicks.
+	remoteClicks = map[string]EventType{
+		"click":              EventClick,
+		"double_click":       EventDoubleClick,
+		"long_click":         EventLongPress,
+		"long_click_press":   EventLongPress,
+		"long_click_release": EventLongRelease,
+	}
+
+	// Remote both buttons clicks.
+	remoteBothClicks = map[string]EventType{
+		"both_click":        EventBothClick,
+		"double_both_click": EventBothDoubleClick,
+		"long_both_click":   EventBothLongPress,
+	}
+)
+
+// RemoteState describes a state of the wireless wall remote.
+type RemoteState struct {
+	BatteryState
+
+	Channels  int
+	LastClick time.Time
+}
+
+// Remote defines an Aqara wireless wall remote.
+type Remote struct {
+	XiaomiDevice
+
+	ID      string
+	Gateway *Gateway
+	state   *RemoteState
+}
+
+// Stops is not uses for gateway devices.
+func (r *Remote) Stop() {
+}
+
+// GetUpdateMessage returns device's state update message.
+func (r *Remote) GetUpdateMessage() *DeviceUpdateMessage {
+	r.Gateway.Lock()
+	defer r.Gateway.Unlock()
+
+	return r.updateMessage()
+}
+
+// Returns device's state update message. Gateway lock must be held.
+func (r *Remote) updateMessage() *DeviceUpdateMessage {
+	return &DeviceUpdateMessage{
+		ID:      r.ID,
+		Model:   r.model,
+		Kind:    UpdateKindState,
+		Version: r.version,
+		State:   r.stateCopy(),
+	}
+}
+
+// Returns a copy of the device state.
+func (r *Remote) stateCopy() interface{} {
+	st := *r.state
+	return &st
+}
+
+// UpdateState performs a device update.
+func (r *Remote) UpdateState() {
+	r.updateBattery(&r.state.BatteryState)
+
+	for ii := 0; ii < r.state.Channels; ii++ {
+		if t, ok := remoteClicks[r.getKeyValue(channelField(ii))]; ok {
+			r.addEventData(t, ii)
+			r.state.LastClick = time.Now()
+		}
+	}
+
+	if t, ok := remoteBothClicks[r.getFieldValue(fieldDualChannel)]; ok {
+		r.addEvent(t)
+		r.state.LastClick = time.Now()
+	}
+}
+
+// Returns number of the remote buttons.
+func remoteChannels(model string) int {
+	switch model {
+	case "86sw1", "sensor_86sw1", "remote.b186acn01":
+		return 1
+	default:
+		return 2
+	}
+}
@@ -2,9 +2,12 @@ package miio
 
 // SensorHTState describes a state of the humidity-temperature sensor.
 type SensorHTState struct {
+	BatteryState
+
 	Temperature float64
 	Humidity    float64
-	Battery     float32
+	// Atmospheric pressure, hPa. Reported by weather.v1 only.
+	Pressure float64
 }
 
 // SensorHT defines a Xiaomi humidity-temperature sensor.
@@ -13,7 +16,7 @@ type SensorHT struct {
 
 	ID      string
 	Gateway *Gateway
-	State   *SensorHTState
+	state   *SensorHTState
 }
 
 // Stops is not uses for gateway devices.
@@ -22,15 +25,41 @@ func (s *SensorHT) Stop() {
 
 // GetUpdateMessage returns device's state update message.
 func (s *SensorHT) GetUpdateMessage() *DeviceUpdateMessage {
+	s.Gateway.Lock()
+	defer s.Gateway.Unlock()
+
+	return s.updateMessage()
+}
+
+// Returns device's state update message. Gateway lock must be held.
+func (s *SensorHT) updateMessage() *DeviceUpdateMessage {
 	return &DeviceUpdateMessage{
-		ID:    s.ID,
-		State: s.State,
+		ID:      s.ID,
+		Model:   s.model,
+		Kind:    UpdateKindState,
+		Version: s.version,
+		State:   s.stateCopy(),
 	}
 }
 
+// Returns a copy of the device state.
+func (s *SensorHT) stateCopy() interface{} {
+	st := *s.state
+	return &st
+}
+
 // UpdateState performs a device update.
 func (s *SensorHT) UpdateState() {
-	s.State.Temperature = s.GetFieldPercentage(fieldTemperature, s.State.Temperature)
-	s.State.Humidity = s.GetFieldPercentage(fieldHumidity, s.State.Humidity)
-	s.State.Battery = s.GetBatteryLevel(s.State.Battery)
+	s.state.Temperature = s.GetFieldPercentage(fieldTemperature, s.state.Temperature)
+	s.state.Humidity = s.GetFieldPercentage(fieldHumidity, s.state.Humidity)
+	if s.hasPressure() {
+		// Pressure is reported in Pa.
+		s.state.Pressure = s.GetFieldPercentage(fieldPressure, s.state.Pressure)
+	}
+	s.updateBattery(&s.state.BatteryState)
+}
+
+// Checks whether sensor reports atmospheric pressure.
+func (s *SensorHT) hasPressure() bool {
+	return "weather.v1" == s.model
 }
@@ -0,0 +1,102 @@
+package miio
+
+import (
+	"sort"
+	"time"
+)
+
+// DeviceSnapshot is an immutable copy of a device state.
+// Version is incremented with every state update.
+type DeviceSnapshot struct {
+	ID       string
+	Model    string
+	Version  uint64
+	Online   bool
+	LastSeen time.Time
+	State    interface{}
+}
+
+// GatewaySnapshot is an immutable copy of the gateway and its sub-devices.
+type GatewaySnapshot struct {
+	Gateway *DeviceSnapshot
+	Devices []*DeviceSnapshot
+}
+
+// Increments the state version.
+func (d *XiaomiDevice) bumpVersion() {
+	d.version++
+}
+
+// Devices returns snapshots of all known sub-devices sorted by ID.
+func (g *Gateway) Devices() []*DeviceSnapshot {
+	g.Lock()
+	defer g.Unlock()
+
+	return g.devicesSnapshot()
+}
+
+// Device returns a snapshot of the sub-device or nil if it's unknown.
+func (g *Gateway) Device(sid string) *DeviceSnapshot {
+	g.Lock()
+	defer g.Unlock()
+
+	d, ok := g.devices[sid]
+	if !ok {
+		return nil
+	}
+
+	return snapshotOf(d)
+}
+
+// SubDevice returns the sub-device or nil if it's unknown.
+// Use a type assertion to reach device commands, e.g. d.(*WallSwitch).On(0).
+func (g *Gateway) SubDevice(sid string) IDevice {
+	g.Lock()
+	defer g.Unlock()
+
+	d, ok := g.devices[sid]
+	if !ok {
+		return nil
+	}
+
+	return d
+}
+
+// Snapshot returns a consistent copy of the gateway and all sub-devices.
+func (g *Gateway) Snapshot() *GatewaySnapshot {
+	g.Lock()
+	defer g.Unlock()
+
+	return &GatewaySnapshot{
+		Gateway: snapshotOf(g),
+		Devices: g.devicesSnapshot(),
+	}
+}
+
+// Returns snapshots of all sub-devices. Gateway lock must be held.
+func (g *Gateway) devicesSnapshot() []*DeviceSnapshot {
+	res := make([]*DeviceSnapshot, 0, len(g.devices))
+	for _, d := range g.devices {
+		res = append(res, snapshotOf(d))
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+
+	return res
+}
+
+// Creates a device snapshot. Gateway lock must be held.
+func snapshotOf(d internalDevice) *DeviceSnapshot {
+	msg := d.updateMessage()
+	a := d.getAvailability().state()
+	return &DeviceSnapshot{
+		ID:       msg.ID,
+		Model:    msg.Model,
+		Version:  msg.Version,
+		Online:   a.Online,
+		LastSeen: a.LastSeen,
+		State:    msg.State,
+	}
+}
@@ -0,0 +1,69 @@
+package miio
+
+import "fmt"
+
+const (
+	// Music id which stops the playback.
+	soundStop = 10000
+	// Gateway reports illumination with this offset.
+	illuminationOffset = 300
+)
+
+// Built-in ringtone id ranges, custom uploaded ringtones start at 10001.
+var ringtoneRanges = [][2]int{
+	// Alarms.
+	{0, 8},
+	// Doorbells.
+	{10, 13},
+	// Alarm clock.
+	{20, 29},
+	// Custom.
+	{10001, 1<<31 - 1},
+}
+
+// PlaySound plays the ringtone with the volume, percent.
+func (g *Gateway) PlaySound(id int, volume uint8) error {
+	if !validRingtone(id) {
+		return fmt.Errorf("invalid ringtone id %d", id)
+	}
+
+	if volume > 100 {
+		volume = 100
+	}
+
+	data := map[string]interface{}{
+		fieldMid.String(): id,
+		fieldVol.String(): volume,
+	}
+
+	return g.stateCommand(data)
+}
+
+// StopSound stops the playback.
+func (g *Gateway) StopSound() error {
+	data := map[string]interface{}{
+		fieldMid.String(): soundStop,
+	}
+
+	return g.stateCommand(data)
+}
+
+// Checks whether ringtone id is known.
+func validRingtone(id int) bool {
+	for _, r := range ringtoneRanges {
+		if id >= r[0] && id <= r[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Converts reported illumination to lux.
+func illuminanceLux(val uint32) uint32 {
+	if val < illuminationOffset {
+		return 0
+	}
+
+	return val - illuminationOffset
+}
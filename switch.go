@@ -1,9 +1,12 @@
 package miio
 
+import "time"
+
 // SwitchState describes a state of the switch.
 type SwitchState struct {
-	Click   ClickType
-	Battery float32
+	BatteryState
+
+	LastClick time.Time
 }
 
 // Switch defines a Xiaomi switch.
@@ -12,7 +15,7 @@ type Switch struct {
 
 	ID      string
 	Gateway *Gateway
-	State   *SwitchState
+	state   *SwitchState
 }
 
 // Stops is not uses for gateway devices.
@@ -21,30 +24,49 @@ func (s *Switch) Stop() {
 
 // GetUpdateMessage returns device's state update message.
 func (s *Switch) GetUpdateMessage() *DeviceUpdateMessage {
+	s.Gateway.Lock()
+	defer s.Gateway.Unlock()
+
+	return s.updateMessage()
+}
+
+// Returns device's state update message. Gateway lock must be held.
+func (s *Switch) updateMessage() *DeviceUpdateMessage {
 	return &DeviceUpdateMessage{
-		ID:    s.ID,
-		State: s.State,
+		ID:      s.ID,
+		Model:   s.model,
+		Kind:    UpdateKindState,
+		Version: s.version,
+		State:   s.stateCopy(),
 	}
 }
 
+// Returns a copy of the device state.
+func (s *Switch) stateCopy() interface{} {
+	st := *s.state
+	return &st
+}
+
 // UpdateState performs a device update.
 func (s *Switch) UpdateState() {
-	s.State.Battery = s.GetBatteryLevel(s.State.Battery)
+	s.updateBattery(&s.state.BatteryState)
 	clType, err := internalClickString(s.getFieldValue(fieldStatus))
 	if err != nil {
-		s.State.Click = ClickNo
+		return
 	}
 
 	switch clType {
 	case clClick:
-		s.State.Click = ClickSingle
+		s.addEvent(EventClick)
 	case clDoubleClick:
-		s.State.Click = ClickDouble
+		s.addEvent(EventDoubleClick)
 	case clLongClickPress:
-		s.State.Click = ClickLongPress
+		s.addEvent(EventLongPress)
 	case clLongClickRelease:
-		s.State.Click = ClickLongRelease
+		s.addEvent(EventLongRelease)
 	default:
-		s.State.Click = ClickNo
+		return
 	}
+
+	s.state.LastClick = time.Now()
 }
@@ -8,6 +8,8 @@ import (
 const (
 	// Number of command retries.
 	vacRetries = 3
+	// Vacuum model name.
+	vacModel = "vacuum"
 )
 
 // VacError defines possible vacuum error.
@@ -93,16 +95,17 @@ type stateResponse struct {
 // Vacuum defines a Xiaomi vacuum cleaner.
 type Vacuum struct {
 	XiaomiDevice
+	*UpdateBus
 	State *VacuumState
-
-	UpdateChan chan *DeviceUpdateMessage
 }
 
 // NewVacuum creates a new vacuum.
 func NewVacuum(deviceIP, token string) (*Vacuum, error) {
 	v := &Vacuum{
-		State: &VacuumState{},
+		UpdateBus: NewUpdateBus(),
+		State:     &VacuumState{},
 		XiaomiDevice: XiaomiDevice{
+			deviceID: deviceIP,
 			rawState: make(map[string]interface{}),
 		},
 	}
@@ -113,43 +116,62 @@ func NewVacuum(deviceIP, token string) (*Vacuum, error) {
 	}
 
 	go v.processUpdates()
-	v.UpdateChan = make(chan *DeviceUpdateMessage, 100)
+	go v.monitorAvailability(v.deviceID, vacModel, v.publish)
 	return v, nil
 }
 
 // Stop stops the device.
 func (v *Vacuum) Stop() {
 	v.stop()
-	close(v.UpdateChan)
+	v.close()
 }
 
 // GetUpdateMessage returns an update message.
 func (v *Vacuum) GetUpdateMessage() *DeviceUpdateMessage {
+	v.Lock()
+	defer v.Unlock()
+
 	return &DeviceUpdateMessage{
-		ID:    v.deviceID,
-		State: v.State,
+		ID:      v.deviceID,
+		Model:   vacModel,
+		Kind:    UpdateKindState,
+		Version: v.version,
+		State:   v.stateCopy(),
 	}
 }
 
+// Returns a copy of the vacuum state.
+func (v *Vacuum) stateCopy() interface{} {
+	st := *v.State
+	return &st
+}
+
 // UpdateState performs a state update.
 func (v *Vacuum) UpdateState() {
+	if v.updateState() {
+		v.publish(v.GetUpdateMessage())
+	}
+}
+
+// Applies the latest status response. Returns true if state was updated.
+func (v *Vacuum) updateState() bool {
 	v.Lock()
 	defer v.Unlock()
 
 	b, ok := v.rawState[cmdGetStatus]
 	if !ok {
-		return
+		return false
 	}
 
 	r := &stateResponse{}
 	err := json.Unmarshal(b.([]byte), r)
 	if err != nil {
 		LOGGER.Error("Failed to un-marshal vacuum response: %s", err.Error())
-		return
+		return false
 	}
 
 	if 0 == len(r.Result) {
-		return
+		return false
 	}
 
 	v.State.Battery = r.Result[0].Battery
@@ -204,7 +226,8 @@ func (v *Vacuum) UpdateState() {
 		v.State.State = VacStateUnknown
 	}
 
-	v.UpdateChan <- v.GetUpdateMessage()
+	v.bumpVersion()
+	return true
 }
 
 // UpdateStatus requests for a state update.
@@ -282,7 +305,12 @@ func (v *Vacuum) SetFanPower(val uint8) bool {
 // Processes internal updates.
 // We care only about state update messages.
 func (v *Vacuum) processUpdates() {
-	for msg := range v.messages {
+	for {
+		msg, ok := v.nextMessage()
+		if !ok {
+			return
+		}
+
 		m := msg.(string)
 		switch m {
 		case cmdGetStatus:
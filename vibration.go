@@ -0,0 +1,142 @@
+package miio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VibrationSensitivity defines vibration sensor sensitivity level.
+type VibrationSensitivity int
+
+const (
+	// VibrationSensitivityLow describes low sensitivity.
+	VibrationSensitivityLow VibrationSensitivity = iota
+	// VibrationSensitivityMedium describes medium sensitivity.
+	VibrationSensitivityMedium
+	// VibrationSensitivityHigh describes high sensitivity.
+	VibrationSensitivityHigh
+)
+
+var (
+	// Vibration sensor events reported in the status field.
+	vibrationEvents = map[string]EventType{
+		"vibrate":   EventVibrate,
+		"tilt":      EventTilt,
+		"free_fall": EventFreeFall,
+	}
+
+	// Sensitivity values accepted by the gateway.
+	vibrationSensitivities = map[VibrationSensitivity]string{
+		VibrationSensitivityLow:    "low",
+		VibrationSensitivityMedium: "medium",
+		VibrationSensitivityHigh:   "high",
+	}
+)
+
+// VibrationState describes a state of the vibration sensor.
+type VibrationState struct {
+	BatteryState
+
+	// Final tilt angle, degrees.
+	TiltAngle int
+	// Acceleration coordinates.
+	X           int
+	Y           int
+	Z           int
+	BedActivity int
+	LastAction  time.Time
+}
+
+// Vibration defines an Aqara vibration sensor.
+type Vibration struct {
+	XiaomiDevice
+
+	ID      string
+	Gateway *Gateway
+	state   *VibrationState
+}
+
+// Stops is not uses for gateway devices.
+func (v *Vibration) Stop() {
+}
+
+// GetUpdateMessage returns device's state update message.
+func (v *Vibration) GetUpdateMessage() *DeviceUpdateMessage {
+	v.Gateway.Lock()
+	defer v.Gateway.Unlock()
+
+	return v.updateMessage()
+}
+
+// Returns device's state update message. Gateway lock must be held.
+func (v *Vibration) updateMessage() *DeviceUpdateMessage {
+	return &DeviceUpdateMessage{
+		ID:      v.ID,
+		Model:   v.model,
+		Kind:    UpdateKindState,
+		Version: v.version,
+		State:   v.stateCopy(),
+	}
+}
+
+// Returns a copy of the device state.
+func (v *Vibration) stateCopy() interface{} {
+	st := *v.state
+	return &st
+}
+
+// UpdateState performs a device update.
+func (v *Vibration) UpdateState() {
+	v.updateBattery(&v.state.BatteryState)
+	v.state.TiltAngle = int(v.GetFieldValueInt32(fieldFinalTiltAngle, int32(v.state.TiltAngle)))
+	v.state.BedActivity = int(v.GetFieldValueInt32(fieldBedActivity, int32(v.state.BedActivity)))
+	v.updateCoordinates()
+
+	if t, ok := vibrationEvents[v.getFieldValue(fieldStatus)]; ok {
+		v.addEvent(t)
+		v.state.LastAction = time.Now()
+	}
+}
+
+// SetSensitivity sets the vibration sensitivity level.
+func (v *Vibration) SetSensitivity(level VibrationSensitivity) error {
+	val, ok := vibrationSensitivities[level]
+	if !ok {
+		return fmt.Errorf("invalid sensitivity level %d", level)
+	}
+
+	data := map[string]interface{}{
+		fieldSensitivity.String(): val,
+	}
+
+	return v.Gateway.write(data, v.ID, v.model)
+}
+
+// Parses coordinates, reported as "x,y,z".
+func (v *Vibration) updateCoordinates() {
+	val := v.getFieldValue(fieldCoordination)
+	if "" == val {
+		return
+	}
+
+	parts := strings.Split(val, ",")
+	if 3 != len(parts) {
+		LOGGER.Warn("Failed to parse vibration coordinates: %s", val)
+		return
+	}
+
+	coords := make([]int, 3)
+	for ii, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			LOGGER.Warn("Failed to parse vibration coordinates: %s", val)
+			return
+		}
+
+		coords[ii] = n
+	}
+
+	v.state.X, v.state.Y, v.state.Z = coords[0], coords[1], coords[2]
+}
@@ -0,0 +1,106 @@
+package miio
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WallSwitchState describes a state of the wired wall switch.
+// Power fields are reported only by ctrl_ln1 and ctrl_ln2.
+type WallSwitchState struct {
+	Channels int
+	Channel0 bool
+	Channel1 bool
+	// Load power, W.
+	LoadPower float64
+	// Consumed energy, Wh.
+	PowerConsumed float64
+}
+
+// WallSwitch defines an Aqara wired wall switch.
+type WallSwitch struct {
+	XiaomiDevice
+
+	ID      string
+	Gateway *Gateway
+	state   *WallSwitchState
+}
+
+// Stops is not uses for gateway devices.
+func (w *WallSwitch) Stop() {
+}
+
+// GetUpdateMessage returns device's state update message.
+func (w *WallSwitch) GetUpdateMessage() *DeviceUpdateMessage {
+	w.Gateway.Lock()
+	defer w.Gateway.Unlock()
+
+	return w.updateMessage()
+}
+
+// Returns device's state update message. Gateway lock must be held.
+func (w *WallSwitch) updateMessage() *DeviceUpdateMessage {
+	return &DeviceUpdateMessage{
+		ID:      w.ID,
+		Model:   w.model,
+		Kind:    UpdateKindState,
+		Version: w.version,
+		State:   w.stateCopy(),
+	}
+}
+
+// Returns a copy of the device state.
+func (w *WallSwitch) stateCopy() interface{} {
+	st := *w.state
+	return &st
+}
+
+// UpdateState performs a device update.
+func (w *WallSwitch) UpdateState() {
+	w.state.Channel0 = w.getKeyValueBool(channelField(0), w.state.Channel0)
+	w.state.Channel1 = w.getKeyValueBool(channelField(1), w.state.Channel1)
+	w.state.LoadPower = w.GetFieldValueFloat64(fieldLoadPower, w.state.LoadPower)
+	w.state.PowerConsumed = w.GetFieldValueFloat64(fieldPowerConsumed, w.state.PowerConsumed)
+}
+
+// On turns the channel on.
+func (w *WallSwitch) On(channel int) error {
+	return w.setChannel(channel, valueOn)
+}
+
+// Off turns the channel off.
+func (w *WallSwitch) Off(channel int) error {
+	return w.setChannel(channel, valueOff)
+}
+
+// Toggle toggles the channel.
+func (w *WallSwitch) Toggle(channel int) error {
+	return w.setChannel(channel, valueToggle)
+}
+
+// Sends the channel state to the gateway.
+func (w *WallSwitch) setChannel(channel int, val string) error {
+	if channel < 0 || channel >= wallSwitchChannels(w.model) {
+		return fmt.Errorf("invalid channel %d for %s", channel, w.model)
+	}
+
+	data := map[string]interface{}{
+		channelField(channel): val,
+	}
+
+	return w.Gateway.write(data, w.ID, w.model)
+}
+
+// Returns number of the wall switch channels.
+func wallSwitchChannels(model string) int {
+	if strings.HasSuffix(model, "2") {
+		return 2
+	}
+
+	return 1
+}
+
+// Returns the channel field name.
+func channelField(channel int) string {
+	return fmt.Sprintf("channel_%d", channel)
+}
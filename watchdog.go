@@ -0,0 +1,106 @@
+package miio
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// Interval between reconnection attempts while gateway is offline.
+	reconnectInterval = 15 * time.Second
+)
+
+var (
+	// ErrGatewayStopped is returned if command is sent to the stopped gateway.
+	ErrGatewayStopped = errors.New("gateway is stopped")
+)
+
+// Reconnects to the gateway when heartbeats stop.
+// Gateway is marked offline by the availability monitor.
+func (g *Gateway) watchdog() {
+	ticker := time.NewTicker(reconnectInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.done:
+			return
+		case <-ticker.C:
+		}
+
+		if g.Online() {
+			continue
+		}
+
+		LOGGER.Warn("Gateway %s is offline, reconnecting", g.ip.String())
+		err := g.reconnect()
+		if err != nil {
+			LOGGER.Error("Failed to reconnect to gateway %s: %s", g.ip.String(), err.Error())
+		}
+	}
+}
+
+// Reopens gateway sockets and requests devices again.
+// Connection is swapped under the lock, so no command is sent to the closed connection.
+func (g *Gateway) reconnect() error {
+	if g.stopped() {
+		return nil
+	}
+
+	c, err := newConnection(g.ip.String(), g.opts.port(), g.localAddr)
+	if err != nil {
+		return err
+	}
+
+	g.connLock.Lock()
+	if g.stopped() {
+		g.connLock.Unlock()
+		c.Close()
+		return nil
+	}
+
+	old := g.conn
+	g.conn = c
+	if nil != g.multiCast {
+		g.multiCast.Close()
+		g.multiCast = nil
+	}
+
+	old.Close()
+	g.connLock.Unlock()
+
+	go g.processGatewayMessages(c)
+
+	if g.ownMCast {
+		l, err := g.opts.listenMultiCast()
+		if err != nil {
+			return err
+		}
+
+		g.connLock.Lock()
+		if g.stopped() {
+			g.connLock.Unlock()
+			l.Close()
+			return nil
+		}
+
+		g.multiCast = l
+		g.connLock.Unlock()
+		go g.listenMultiCast(l)
+	}
+
+	return g.getDevices()
+}
+
+// Sends the command over the current connection.
+// Holds the connection lock, so connection can't be closed while sending.
+func (g *Gateway) send(cmd *command) error {
+	g.connLock.RLock()
+	defer g.connLock.RUnlock()
+
+	if g.stopped() {
+		return ErrGatewayStopped
+	}
+
+	return g.conn.Send(cmd)
+}
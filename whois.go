@@ -0,0 +1,161 @@
+package miio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	whoisPort = 4321
+	cmdWhoIs  = "whois"
+	cmdIAm    = "iam"
+
+	// Discovery timeout used if context has no deadline.
+	defaultDiscoveryTimeout = 3 * time.Second
+)
+
+// GatewayInfo describes a discovered gateway.
+type GatewayInfo struct {
+	IP    string
+	Port  int
+	SID   string
+	Model string
+}
+
+// Gateway iam response.
+type iamResponse struct {
+	Cmd   string      `json:"cmd"`
+	IP    string      `json:"ip"`
+	Port  interface{} `json:"port"`
+	Sid   string      `json:"sid"`
+	Model string      `json:"model"`
+}
+
+// DiscoverGateways sends multi-cast whois request and collects responses
+// until context is done.
+func DiscoverGateways(ctx context.Context) ([]*GatewayInfo, error) {
+	return DiscoverGatewaysWithOptions(ctx, nil)
+}
+
+// DiscoverGatewaysWithOptions discovers gateways using the multi-cast interface and group from options.
+func DiscoverGatewaysWithOptions(ctx context.Context, opts *GatewayOptions) ([]*GatewayInfo, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultDiscoveryTimeout)
+		defer cancel()
+	}
+
+	addr, err := opts.whoisAddr()
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := opts.joinMultiCast(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	defer l.Close()
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	whois, _ := json.Marshal(&command{Cmd: cmdWhoIs})
+	_, err = l.WriteToUDP(whois, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]*GatewayInfo)
+	buf := make([]byte, 2048)
+	for {
+		size, _, err := l.ReadFromUDP(buf)
+		if err != nil {
+			break
+		}
+
+		info, err := parseIAm(buf[:size])
+		if err != nil {
+			continue
+		}
+
+		if _, ok := found[info.SID]; !ok {
+			LOGGER.Debug("Discovered gateway %s at %s", info.SID, info.IP)
+			found[info.SID] = info
+		}
+	}
+
+	res := make([]*GatewayInfo, 0, len(found))
+	for _, v := range found {
+		res = append(res, v)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].SID < res[j].SID
+	})
+
+	return res, nil
+}
+
+// NewGatewayBySID discovers the gateway with the given SID and connects to it.
+func NewGatewayBySID(ctx context.Context, sid, aesKey string) (*Gateway, error) {
+	return NewGatewayBySIDWithOptions(ctx, sid, aesKey, nil)
+}
+
+// NewGatewayBySIDWithOptions discovers the gateway with the given SID and connects to it using options.
+// Discovered port is used unless options set one.
+func NewGatewayBySIDWithOptions(ctx context.Context, sid, aesKey string, opts *GatewayOptions) (*Gateway, error) {
+	gateways, err := DiscoverGatewaysWithOptions(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range gateways {
+		if v.SID != sid {
+			continue
+		}
+
+		o := GatewayOptions{}
+		if nil != opts {
+			o = *opts
+		}
+
+		if 0 == o.Port {
+			o.Port = v.Port
+		}
+
+		return NewGatewayWithOptions(v.IP, aesKey, &o)
+	}
+
+	return nil, fmt.Errorf("gateway %s was not found", sid)
+}
+
+// Parses iam response.
+func parseIAm(msg []byte) (*GatewayInfo, error) {
+	r := &iamResponse{}
+	err := json.Unmarshal(msg, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if cmdIAm != r.Cmd {
+		return nil, fmt.Errorf("unexpected command %s", r.Cmd)
+	}
+
+	port, err := strconv.Atoi(fmt.Sprintf("%v", r.Port))
+	if err != nil {
+		port = gatewayPort
+	}
+
+	return &GatewayInfo{
+		IP:    r.IP,
+		Port:  port,
+		SID:   r.Sid,
+		Model: r.Model,
+	}, nil
+}